@@ -4,49 +4,65 @@ import (
 	"context"
 	"sync"
 
+	"github.com/sanbsy/gopkg/errors"
 	"github.com/sanbsy/gopkg/log"
 )
 
 // Runner 异步运行器
 type Runner struct {
 	wg *sync.WaitGroup
+
+	mu   sync.Mutex
+	errs *errors.MultiError
 }
 
 // NewRunner 初始化 runner
 func NewRunner() *Runner {
 	return &Runner{
-		wg: &sync.WaitGroup{},
+		wg:   &sync.WaitGroup{},
+		errs: &errors.MultiError{},
 	}
 }
 
-// Run 异步运行一个任务
+// Run 异步运行一个任务，任务中的 panic 会被恢复并记录日志
 func (runner *Runner) Run(fn func()) {
+	runner.RunCtx(context.Background(), fn)
+}
+
+// RunCtx 异步运行一个任务，并接收一个Context
+func (runner *Runner) RunCtx(ctx context.Context, fn func()) {
 	runner.wg.Add(1)
 	go func() {
-		defer func() {
-			if err := recover(); err != nil {
-				log.WarnF("recover a panic:%v", err)
-			}
-			runner.wg.Done()
-		}()
-		fn()
+		defer runner.wg.Done()
+		if err := errors.Try(func() error { fn(); return nil }); err != nil {
+			log.ExtractLogger(ctx).WithError(err).Warn("recover a panic")
+		}
 	}()
 }
 
-// RunCtx 异步运行一个任务，并接收一个Context
-func (runner *Runner) RunCtx(ctx context.Context, fn func()) {
+// Go 异步运行一个返回错误的任务，panic 会被转换为 *errors.PanicError
+// 所有任务的错误通过 Wait 汇总返回
+func (runner *Runner) Go(fn func() error) {
 	runner.wg.Add(1)
 	go func() {
-		defer func() {
-			if err := recover(); err != nil {
-				log.WarnCtxF(ctx, "recover a panic:%v", err)
-			}
-			runner.wg.Done()
-		}()
-		fn()
+		defer runner.wg.Done()
+		if err := errors.Try(fn); err != nil {
+			runner.mu.Lock()
+			runner.errs.Append(err)
+			runner.mu.Unlock()
+		}
 	}()
 }
 
+// Wait 等待所有任务执行完成，返回 Go 运行的任务产生的错误
+func (runner *Runner) Wait() error {
+	runner.Await()
+
+	runner.mu.Lock()
+	defer runner.mu.Unlock()
+	return runner.errs.ErrorOrNil()
+}
+
 // Await 等待所有任务执行完成
 func (runner *Runner) Await() {
 	runner.wg.Wait()
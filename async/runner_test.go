@@ -2,9 +2,11 @@ package async
 
 import (
 	"context"
+	"io"
 	"testing"
 	"time"
 
+	"github.com/sanbsy/gopkg/errors"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -59,7 +61,8 @@ func TestRunner_AwaitWithContext(t *testing.T) {
 		v3 = 3
 	})
 
-	ctx, _ := context.WithTimeout(context.Background(), 6*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), 6*time.Second)
+	defer cancel()
 
 	err := runner.AwaitWithContext(ctx)
 	assert.Equal(t, context.DeadlineExceeded, err)
@@ -67,3 +70,30 @@ func TestRunner_AwaitWithContext(t *testing.T) {
 	assert.Equal(t, 2, v2)
 	assert.Equal(t, 0, v3)
 }
+
+func TestRunner_Go(t *testing.T) {
+	runner := NewRunner()
+
+	runner.Run(func() {
+		panic("ignored")
+	})
+	runner.Go(func() error {
+		return nil
+	})
+	runner.Go(func() error {
+		return io.EOF
+	})
+	runner.Go(func() error {
+		panic("boom")
+	})
+
+	err := runner.Wait()
+	var merr *errors.MultiError
+	assert.True(t, errors.As(err, &merr))
+	assert.Equal(t, 2, merr.Len())
+	assert.True(t, errors.Is(err, io.EOF))
+
+	var perr *errors.PanicError
+	assert.True(t, errors.As(err, &perr))
+	assert.Equal(t, "boom", perr.Value)
+}
@@ -56,7 +56,7 @@ func (b *Buffer) WriteByte(v byte) {
 
 // Free 释放 buffer
 func (b *Buffer) Free() {
-	b.pool.pl.Put(b)
+	b.pool.put(b)
 }
 
 // WriteBytes 写入 []byte
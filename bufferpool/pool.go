@@ -4,32 +4,46 @@ import (
 	"sync"
 )
 
-const defaultCap = 256
+const (
+	defaultCap = 256
+	// maxRetainCap 超过该容量的 buffer 不再放回 pool，避免长期占用大块内存
+	maxRetainCap = 1 << 20
+)
 
 // Pool buffer pool
 type Pool struct {
-	pl *sync.Pool
+	pl    *sync.Pool
+	stats *poolStats
 }
 
 // NewPool create a pool
 func NewPool() Pool {
-	return Pool{pl: &sync.Pool{
-		New: func() interface{} {
-			return &Buffer{
-				buf: make([]byte, 0, defaultCap),
-			}
+	stats := &poolStats{}
+	return Pool{
+		stats: stats,
+		pl: &sync.Pool{
+			New: func() interface{} {
+				stats.allocated()
+				return &Buffer{
+					buf: make([]byte, 0, defaultCap),
+				}
+			},
 		},
-	}}
+	}
 }
 
 // Get get a buffer from pool
 func (p Pool) Get() *Buffer {
 	buf := p.pl.Get().(*Buffer)
+	p.stats.got()
 	buf.Reset()
 	buf.pool = p
 	return buf
 }
 
 func (p Pool) put(buf *Buffer) {
+	if !p.stats.freed(buf.Cap()) {
+		return
+	}
 	p.pl.Put(buf)
 }
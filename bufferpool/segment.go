@@ -0,0 +1,166 @@
+package bufferpool
+
+import (
+	"io"
+	"net"
+	"sync"
+)
+
+// DefaultChunkSize 分段 buffer 默认的分片大小
+const DefaultChunkSize = 64 * 1024
+
+// 按分片大小缓存的分片池
+var chunkPools sync.Map
+
+func chunkPool(size int) *sync.Pool {
+	if pl, ok := chunkPools.Load(size); ok {
+		return pl.(*sync.Pool)
+	}
+	pl, _ := chunkPools.LoadOrStore(size, &sync.Pool{
+		New: func() interface{} {
+			chunk := make([]byte, 0, size)
+			return &chunk
+		},
+	})
+	return pl.(*sync.Pool)
+}
+
+// SegmentBuffer 由多个定长分片组成的 buffer
+// 适用于拼装大体积数据（批量 SQL、导出文件等），写入时不会因扩容而整体复制
+type SegmentBuffer struct {
+	chunks    []*[]byte
+	chunkSize int
+	size      int
+	pool      *sync.Pool
+}
+
+// NewSegmentBuffer 以默认分片大小创建 SegmentBuffer
+func NewSegmentBuffer() *SegmentBuffer {
+	return NewSegmentBufferWithSize(DefaultChunkSize)
+}
+
+// NewSegmentBufferWithSize 以指定分片大小创建 SegmentBuffer
+func NewSegmentBufferWithSize(chunkSize int) *SegmentBuffer {
+	if chunkSize <= 0 {
+		chunkSize = DefaultChunkSize
+	}
+	return &SegmentBuffer{
+		chunkSize: chunkSize,
+		pool:      chunkPool(chunkSize),
+	}
+}
+
+// Len 获取已写入的字节数
+func (b *SegmentBuffer) Len() int {
+	return b.size
+}
+
+// Chunks 获取当前分片数量
+func (b *SegmentBuffer) Chunks() int {
+	return len(b.chunks)
+}
+
+// Write 实现 io.Writer 接口
+func (b *SegmentBuffer) Write(bs []byte) (int, error) {
+	n := len(bs)
+	for len(bs) > 0 {
+		chunk := b.tail()
+		free := cap(*chunk) - len(*chunk)
+		if free > len(bs) {
+			free = len(bs)
+		}
+		*chunk = append(*chunk, bs[:free]...)
+		bs = bs[free:]
+	}
+	b.size += n
+	return n, nil
+}
+
+// WriteString 写入 string
+func (b *SegmentBuffer) WriteString(s string) (int, error) {
+	n := len(s)
+	for len(s) > 0 {
+		chunk := b.tail()
+		free := cap(*chunk) - len(*chunk)
+		if free > len(s) {
+			free = len(s)
+		}
+		*chunk = append(*chunk, s[:free]...)
+		s = s[free:]
+	}
+	b.size += n
+	return n, nil
+}
+
+// WriteByte 写入一个 byte
+func (b *SegmentBuffer) WriteByte(c byte) error {
+	chunk := b.tail()
+	*chunk = append(*chunk, c)
+	b.size++
+	return nil
+}
+
+// WriteTo 实现 io.WriterTo 接口，以 writev 方式一次性输出所有分片
+// 与 bytes.Buffer 不同，输出后不会清空内容，如需复用请调用 Reset
+func (b *SegmentBuffer) WriteTo(w io.Writer) (int64, error) {
+	bufs := make(net.Buffers, 0, len(b.chunks))
+	for _, chunk := range b.chunks {
+		if len(*chunk) > 0 {
+			bufs = append(bufs, *chunk)
+		}
+	}
+	return bufs.WriteTo(w)
+}
+
+// Reader 返回一个只读视图，读取不会影响 buffer 中的内容
+// 在 Reader 读取完毕前，不可对 buffer 进行写入或 Reset
+func (b *SegmentBuffer) Reader() io.Reader {
+	return &segmentReader{buf: b}
+}
+
+// Reset 清除内容，并将所有分片归还分片池
+func (b *SegmentBuffer) Reset() {
+	for i, chunk := range b.chunks {
+		*chunk = (*chunk)[:0]
+		b.pool.Put(chunk)
+		b.chunks[i] = nil
+	}
+	b.chunks = b.chunks[:0]
+	b.size = 0
+}
+
+// tail 获取最后一个仍有剩余空间的分片，已满时从分片池中申请新分片
+func (b *SegmentBuffer) tail() *[]byte {
+	if n := len(b.chunks); n > 0 {
+		if chunk := b.chunks[n-1]; len(*chunk) < cap(*chunk) {
+			return chunk
+		}
+	}
+	chunk := b.pool.Get().(*[]byte)
+	b.chunks = append(b.chunks, chunk)
+	return chunk
+}
+
+type segmentReader struct {
+	buf    *SegmentBuffer
+	chunk  int
+	offset int
+}
+
+func (r *segmentReader) Read(p []byte) (int, error) {
+	var n int
+	for n < len(p) && r.chunk < len(r.buf.chunks) {
+		chunk := *r.buf.chunks[r.chunk]
+		c := copy(p[n:], chunk[r.offset:])
+		n += c
+		r.offset += c
+		if r.offset >= len(chunk) {
+			r.chunk++
+			r.offset = 0
+		}
+	}
+	if n == 0 && len(p) > 0 {
+		return 0, io.EOF
+	}
+	return n, nil
+}
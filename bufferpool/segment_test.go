@@ -0,0 +1,41 @@
+package bufferpool_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/sanbsy/gopkg/bufferpool"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSegmentBuffer(t *testing.T) {
+	ass := assert.New(t)
+
+	buf := bufferpool.NewSegmentBufferWithSize(8)
+	defer buf.Reset()
+
+	expect := strings.Repeat("insert into t values (1);", 10)
+	_, _ = buf.WriteString(expect[:100])
+	_, _ = buf.Write([]byte(expect[100:]))
+	ass.Equal(len(expect), buf.Len())
+	ass.Equal((len(expect)+7)/8, buf.Chunks())
+
+	out := &bytes.Buffer{}
+	n, err := buf.WriteTo(out)
+	ass.Nil(err)
+	ass.Equal(int64(len(expect)), n)
+	ass.Equal(expect, out.String())
+
+	data, err := ioutil.ReadAll(buf.Reader())
+	ass.Nil(err)
+	ass.Equal(expect, string(data))
+
+	buf.Reset()
+	ass.Equal(0, buf.Len())
+	ass.Equal(0, buf.Chunks())
+
+	ass.Nil(buf.WriteByte('x'))
+	ass.Equal(1, buf.Len())
+}
@@ -4,4 +4,12 @@ var (
 	_pool = NewPool()
 	// Get 从全局 _pool 中获取 buffer
 	Get = _pool.Get
+	// EnableStats 开启或关闭全局 _pool 的统计
+	EnableStats = _pool.EnableStats
+	// PoolStats 获取全局 _pool 的运行统计
+	PoolStats = _pool.Stats
+	// PublishExpvar 将全局 _pool 的统计信息发布到 expvar
+	PublishExpvar = _pool.PublishExpvar
+	// WritePrometheus 以 Prometheus 文本格式输出全局 _pool 的统计信息
+	WritePrometheus = _pool.WritePrometheus
 )
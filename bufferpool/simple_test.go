@@ -1,6 +1,7 @@
 package bufferpool_test
 
 import (
+	"bytes"
 	"testing"
 
 	"github.com/sanbsy/gopkg/bufferpool"
@@ -49,3 +50,28 @@ func Test_Get(t *testing.T) {
 		})
 	}
 }
+
+func TestPool_Stats(t *testing.T) {
+	ass := assert.New(t)
+
+	pool := bufferpool.NewPool()
+	pool.EnableStats(true)
+
+	buf := pool.Get()
+	buf.WriteString("hello world")
+	buf.Free()
+
+	big := pool.Get()
+	big.WriteBytes(make([]byte, 2<<20))
+	big.Free()
+
+	stats := pool.Stats()
+	ass.Equal(uint64(1), stats.Freed)
+	ass.Equal(uint64(1), stats.Dropped)
+	ass.Equal(stats.Allocated+stats.Reused, uint64(2))
+	ass.True(stats.MaxCap >= 2<<20)
+
+	out := &bytes.Buffer{}
+	ass.Nil(pool.WritePrometheus(out, "gopkg_bufferpool"))
+	ass.Contains(out.String(), "gopkg_bufferpool_dropped_total 1\n")
+}
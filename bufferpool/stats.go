@@ -0,0 +1,126 @@
+package bufferpool
+
+import (
+	"expvar"
+	"fmt"
+	"io"
+	"sync/atomic"
+)
+
+// Stats pool 运行统计
+type Stats struct {
+	// Allocated 新分配的 buffer 数量
+	Allocated uint64 `json:"allocated"`
+	// Reused 从 pool 中复用的 buffer 数量
+	Reused uint64 `json:"reused"`
+	// Freed 放回 pool 的 buffer 数量
+	Freed uint64 `json:"freed"`
+	// Dropped 因容量过大而丢弃的 buffer 数量
+	Dropped uint64 `json:"dropped"`
+	// MaxCap 观测到的 buffer 最大容量
+	MaxCap uint64 `json:"max_cap"`
+}
+
+// 计数字段需保持 64 位对齐，放在结构体开头
+type poolStats struct {
+	gets    uint64
+	allocs  uint64
+	frees   uint64
+	drops   uint64
+	maxCap  uint64
+	enabled int32
+}
+
+func (s *poolStats) on() bool {
+	return atomic.LoadInt32(&s.enabled) == 1
+}
+
+func (s *poolStats) allocated() {
+	if s.on() {
+		atomic.AddUint64(&s.allocs, 1)
+	}
+}
+
+func (s *poolStats) got() {
+	if s.on() {
+		atomic.AddUint64(&s.gets, 1)
+	}
+}
+
+// freed 记录 buffer 的释放，返回 false 表示 buffer 过大需要丢弃
+func (s *poolStats) freed(capacity int) bool {
+	retain := capacity <= maxRetainCap
+	if !s.on() {
+		return retain
+	}
+
+	if retain {
+		atomic.AddUint64(&s.frees, 1)
+	} else {
+		atomic.AddUint64(&s.drops, 1)
+	}
+	for c := uint64(capacity); ; {
+		old := atomic.LoadUint64(&s.maxCap)
+		if c <= old || atomic.CompareAndSwapUint64(&s.maxCap, old, c) {
+			break
+		}
+	}
+	return retain
+}
+
+// EnableStats 开启或关闭统计，关闭时仅有一次原子读的开销
+func (p Pool) EnableStats(enable bool) {
+	var v int32
+	if enable {
+		v = 1
+	}
+	atomic.StoreInt32(&p.stats.enabled, v)
+}
+
+// Stats 获取 pool 运行统计
+func (p Pool) Stats() Stats {
+	s := Stats{
+		Allocated: atomic.LoadUint64(&p.stats.allocs),
+		Freed:     atomic.LoadUint64(&p.stats.frees),
+		Dropped:   atomic.LoadUint64(&p.stats.drops),
+		MaxCap:    atomic.LoadUint64(&p.stats.maxCap),
+	}
+	if gets := atomic.LoadUint64(&p.stats.gets); gets > s.Allocated {
+		s.Reused = gets - s.Allocated
+	}
+	return s
+}
+
+// PublishExpvar 以指定名称将统计信息发布到 expvar
+// 名称重复时 expvar 会 panic，同一名称只能发布一次
+func (p Pool) PublishExpvar(name string) {
+	expvar.Publish(name, expvar.Func(func() interface{} {
+		return p.Stats()
+	}))
+}
+
+// WritePrometheus 以 Prometheus 文本格式输出统计信息，name 作为指标前缀
+func (p Pool) WritePrometheus(w io.Writer, name string) error {
+	s := p.Stats()
+	metrics := []struct {
+		name  string
+		typ   string
+		help  string
+		value uint64
+	}{
+		{"allocated_total", "counter", "Number of newly allocated buffers.", s.Allocated},
+		{"reused_total", "counter", "Number of buffers reused from the pool.", s.Reused},
+		{"freed_total", "counter", "Number of buffers returned to the pool.", s.Freed},
+		{"dropped_total", "counter", "Number of oversized buffers dropped instead of pooled.", s.Dropped},
+		{"max_cap_bytes", "gauge", "High-water capacity of buffers seen by the pool.", s.MaxCap},
+	}
+
+	for _, m := range metrics {
+		_, err := fmt.Fprintf(w, "# HELP %[1]s_%[2]s %[3]s\n# TYPE %[1]s_%[2]s %[4]s\n%[1]s_%[2]s %[5]d\n",
+			name, m.name, m.help, m.typ, m.value)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+// goaudit 校验 audit 包生成的审计日志
+//
+// 用法：
+//
+//	goaudit [flags] file
+//
+// 示例：
+//
+//	goaudit -pubkey audit.pub audit.log
+//
+// 发现问题时以状态码 1 退出
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/sanbsy/gopkg/log/audit"
+	"github.com/sanbsy/gopkg/secret"
+)
+
+func main() {
+	var (
+		backupPath       = flag.String("backup-path", "", "备份文件目录，默认为日志文件所在目录")
+		suffixFormat     = flag.String("suffix-format", "", "备份文件的时间后缀格式，默认与 RotateWriter 一致")
+		pubkey           = flag.String("pubkey", "", "PEM 格式的 RSA 或 ECDSA 公钥文件，为空时不校验签名")
+		allowMissingHead = flag.Bool("allow-missing-head", false, "允许开头的记录缺失，如旧的备份文件已被清理")
+	)
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [flags] file\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	opts := &audit.VerifyOptions{SuffixFormat: *suffixFormat, AllowMissingHead: *allowMissingHead}
+	if *pubkey != "" {
+		data, err := ioutil.ReadFile(*pubkey)
+		if err != nil {
+			fatal(err)
+		}
+		if opts.RSAKey, opts.ECDSAKey, err = parsePublicKey(data); err != nil {
+			fatal(err)
+		}
+	}
+
+	report, err := audit.Verify(flag.Arg(0), *backupPath, opts)
+	if err != nil {
+		fatal(err)
+	}
+	for _, p := range report.Problems {
+		fmt.Println(p)
+	}
+	fmt.Printf("files: %d, records: %d, seq: %d-%d, signatures: %d, unsigned: %d, problems: %d\n",
+		len(report.Files), report.Records, report.FirstSeq, report.LastSeq,
+		report.Signatures, report.Unsigned, len(report.Problems))
+	if !report.OK() {
+		os.Exit(1)
+	}
+}
+
+// parsePublicKey 解析 RSA 或 ECDSA 公钥
+func parsePublicKey(data []byte) (*rsa.PublicKey, *ecdsa.PublicKey, error) {
+	if key, err := secret.ParseRSAPublicKey(data); err == nil {
+		return key, nil, nil
+	}
+	key, err := secret.ParseECDSAPublic(data)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid public key: %v", err)
+	}
+	return nil, key, nil
+}
+
+func fatal(err error) {
+	fmt.Fprintln(os.Stderr, "goaudit:", err)
+	os.Exit(1)
+}
@@ -0,0 +1,168 @@
+// gologs 读取、过滤、跟踪 log 包生成的日志文件
+//
+// 用法：
+//
+//	gologs [flags] file...
+//
+// 示例：
+//
+//	gologs -rotated -level warn -since 1h app.log
+//	gologs -f -where user=tom -where status>=500 app.log
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/sanbsy/gopkg/log"
+	"github.com/sanbsy/gopkg/log/reader"
+)
+
+type exprs []reader.Expr
+
+func (e *exprs) String() string {
+	s := make([]string, len(*e))
+	for i, x := range *e {
+		s[i] = x.Key + x.Op + x.Value
+	}
+	return strings.Join(s, ",")
+}
+
+func (e *exprs) Set(s string) error {
+	x, err := reader.ParseExpr(s)
+	if err != nil {
+		return err
+	}
+	*e = append(*e, x)
+	return nil
+}
+
+func main() {
+	var (
+		follow       = flag.Bool("f", false, "持续输出新写入的日志，跟随文件切割")
+		rotated      = flag.Bool("rotated", false, "同时读取切割后的备份文件")
+		backupPath   = flag.String("backup-path", "", "备份文件目录，默认为日志文件所在目录")
+		suffixFormat = flag.String("suffix-format", "", "备份文件的时间后缀格式，默认与 RotateWriter 一致")
+		level        = flag.String("level", "", "最低日志等级：debug、info、warn、error、dpanic、panic、fatal")
+		since        = flag.String("since", "", "开始时间，RFC3339 格式或相对时长，如 2h、30m")
+		until        = flag.String("until", "", "结束时间，格式同 -since")
+		logId        = flag.String("id", "", "只输出指定 "+log.IdKey+" 的日志")
+		noColor      = flag.Bool("no-color", false, "关闭颜色")
+		raw          = flag.Bool("raw", false, "输出原始日志行")
+		where        exprs
+	)
+	flag.Var(&where, "where", "字段表达式，可重复，如 user=tom、status>=500、@message~timeout")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [flags] file...\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	now := time.Now()
+	filter := &reader.Filter{LogId: *logId, Exprs: where}
+	var err error
+	if *level != "" {
+		var l log.Level
+		if err = l.UnmarshalText([]byte(*level)); err != nil {
+			fatal(err)
+		}
+		filter.Level = l
+	}
+	if filter.Since, err = parseTime(*since, now); err != nil {
+		fatal(err)
+	}
+	if filter.Until, err = parseTime(*until, now); err != nil {
+		fatal(err)
+	}
+
+	var mu sync.Mutex
+	printer := reader.NewPrinter(os.Stdout, !*noColor && isTerminal(os.Stdout))
+	output := func(e *reader.Entry) error {
+		if !filter.Match(e) {
+			return nil
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		if *raw {
+			_, err := os.Stdout.Write(append(e.Raw, '\n'))
+			return err
+		}
+		return printer.Print(e)
+	}
+
+	for _, name := range flag.Args() {
+		files := []string{name}
+		if *rotated {
+			if files, err = reader.Files(name, *backupPath, *suffixFormat); err != nil {
+				fatal(err)
+			}
+		} else if *follow {
+			// 跟踪时由 Follow 从头读取当前文件
+			files = nil
+		}
+		if err = reader.ReadFiles(files, output); err != nil {
+			fatal(err)
+		}
+	}
+	if !*follow {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		ch := make(chan os.Signal, 1)
+		signal.Notify(ch, os.Interrupt, syscall.SIGTERM)
+		<-ch
+		cancel()
+	}()
+	var wg sync.WaitGroup
+	for _, name := range flag.Args() {
+		wg.Add(1)
+		go func(name string) {
+			defer wg.Done()
+			// 已读取备份文件时当前文件也已读取，从末尾开始跟踪
+			opts := &reader.FollowOptions{FromStart: !*rotated}
+			if err := reader.Follow(ctx, name, opts, output); err != nil {
+				fatal(err)
+			}
+		}(name)
+	}
+	wg.Wait()
+}
+
+// parseTime 解析 RFC3339 时间或相对于 now 的时长
+func parseTime(s string, now time.Time) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if d, err := time.ParseDuration(s); err == nil {
+		return now.Add(-d), nil
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time %q, expected RFC3339 or duration", s)
+	}
+	return t, nil
+}
+
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+func fatal(err error) {
+	fmt.Fprintln(os.Stderr, "gologs:", err)
+	os.Exit(1)
+}
@@ -0,0 +1,218 @@
+package errors
+
+import (
+	"fmt"
+	"io"
+	"sync"
+)
+
+// Category 错误分类
+type Category uint8
+
+// 错误分类，与常见的 RPC 状态码保持一致
+const (
+	Unknown Category = iota
+	InvalidArgument
+	NotFound
+	AlreadyExists
+	PermissionDenied
+	Unauthenticated
+	ResourceExhausted
+	FailedPrecondition
+	Canceled
+	DeadlineExceeded
+	Unavailable
+	Unimplemented
+	Internal
+)
+
+var categoryNames = [...]string{
+	Unknown:            "unknown",
+	InvalidArgument:    "invalid_argument",
+	NotFound:           "not_found",
+	AlreadyExists:      "already_exists",
+	PermissionDenied:   "permission_denied",
+	Unauthenticated:    "unauthenticated",
+	ResourceExhausted:  "resource_exhausted",
+	FailedPrecondition: "failed_precondition",
+	Canceled:           "canceled",
+	DeadlineExceeded:   "deadline_exceeded",
+	Unavailable:        "unavailable",
+	Unimplemented:      "unimplemented",
+	Internal:           "internal",
+}
+
+func (c Category) String() string {
+	if int(c) < len(categoryNames) {
+		return categoryNames[c]
+	}
+	return categoryNames[Unknown]
+}
+
+// ParseCategory 根据名称获取错误分类，未知名称返回 Unknown
+func ParseCategory(name string) Category {
+	for c, n := range categoryNames {
+		if n == name {
+			return Category(c)
+		}
+	}
+	return Unknown
+}
+
+// CodedError 带错误码的错误
+// 通过 Define 注册的实例作为哨兵错误使用，
+// 通过 WithDetail、Wrap 派生的错误与哨兵错误 errors.Is 判定相等
+type CodedError struct {
+	code     string
+	category Category
+	message  string
+	detail   string
+	cause    error
+	*stack
+}
+
+var registry sync.Map
+
+// Define 注册错误码，返回哨兵错误，错误码重复注册时 panic
+// message 为可以直接展示给用户的信息
+func Define(code string, category Category, message string) *CodedError {
+	e := &CodedError{
+		code:     code,
+		category: category,
+		message:  message,
+	}
+	if _, loaded := registry.LoadOrStore(code, e); loaded {
+		panic("errors: duplicate error code " + code)
+	}
+	return e
+}
+
+// Lookup 根据错误码查询已注册的哨兵错误
+func Lookup(code string) (*CodedError, bool) {
+	e, ok := registry.Load(code)
+	if !ok {
+		return nil, false
+	}
+	return e.(*CodedError), true
+}
+
+// WithDetail 派生错误并附加内部详情，记录调用栈
+func (e *CodedError) WithDetail(detail string) error {
+	return &CodedError{
+		code:     e.code,
+		category: e.category,
+		message:  e.message,
+		detail:   detail,
+		stack:    callers(),
+	}
+}
+
+// WithDetailf 派生错误并附加格式化的内部详情
+func (e *CodedError) WithDetailf(format string, args ...interface{}) error {
+	return &CodedError{
+		code:     e.code,
+		category: e.category,
+		message:  e.message,
+		detail:   fmt.Sprintf(format, args...),
+		stack:    callers(),
+	}
+}
+
+// Wrap 以当前错误码包装 cause，cause 为 nil 时返回 nil
+func (e *CodedError) Wrap(cause error, detail string) error {
+	if cause == nil {
+		return nil
+	}
+	w := &CodedError{
+		code:     e.code,
+		category: e.category,
+		message:  e.message,
+		detail:   detail,
+		cause:    cause,
+	}
+	if !hasStack(cause) {
+		w.stack = callers()
+	}
+	return w
+}
+
+// Code 错误码
+func (e *CodedError) Code() string { return e.code }
+
+// Category 错误分类
+func (e *CodedError) Category() Category { return e.category }
+
+// Message 可展示给用户的信息
+func (e *CodedError) Message() string { return e.message }
+
+// Detail 内部详情，不应展示给用户
+func (e *CodedError) Detail() string { return e.detail }
+
+func (e *CodedError) Error() string {
+	msg := e.message
+	if e.detail != "" {
+		msg += ": " + e.detail
+	}
+	if e.cause != nil {
+		msg += ": " + e.cause.Error()
+	}
+	return msg
+}
+
+func (e *CodedError) Unwrap() error { return e.cause }
+
+// Is 错误码相同即视为相等
+func (e *CodedError) Is(target error) bool {
+	t, ok := target.(*CodedError)
+	return ok && t.code == e.code
+}
+
+func (e *CodedError) Format(s fmt.State, verb rune) {
+	switch verb {
+	case 'v':
+		if s.Flag('+') {
+			if e.cause != nil {
+				_, _ = fmt.Fprintf(s, "%+v\n", e.cause)
+			}
+			_, _ = fmt.Fprintf(s, "[%s] %s", e.code, e.message)
+			if e.detail != "" {
+				_, _ = io.WriteString(s, ": "+e.detail)
+			}
+			e.stack.Format(s, verb)
+			return
+		}
+		fallthrough
+	case 's', 'q':
+		_, _ = io.WriteString(s, e.Error())
+	}
+}
+
+type coder interface {
+	Code() string
+	Category() Category
+}
+
+// Code 获取错误链中第一个错误码，没有时返回空字符串
+func Code(err error) string {
+	var c coder
+	if As(err, &c) {
+		return c.Code()
+	}
+	return ""
+}
+
+// CategoryOf 获取错误链中第一个错误分类，
+// 没有时匹配通过 RegisterSentinel 注册的哨兵错误，均未匹配返回 Unknown
+func CategoryOf(err error) Category {
+	var c coder
+	if As(err, &c) {
+		return c.Category()
+	}
+	category, _ := sentinelCategory(err)
+	return category
+}
+
+// IsCategory 判断错误是否属于指定分类
+func IsCategory(err error, category Category) bool {
+	return err != nil && CategoryOf(err) == category
+}
@@ -0,0 +1,32 @@
+package errors_test
+
+import (
+	"io"
+	"testing"
+
+	"github.com/sanbsy/gopkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+var errUserNotFound = errors.Define("test.user_not_found", errors.NotFound, "user not found")
+
+func TestCodedError(t *testing.T) {
+	ass := assert.New(t)
+
+	err := errors.Wrap(errUserNotFound.Wrap(io.EOF, "uid=1"), "load user")
+	ass.Equal("load user: user not found: uid=1: EOF", err.Error())
+	ass.True(errors.Is(err, errUserNotFound))
+	ass.True(errors.Is(err, io.EOF))
+	ass.Equal("test.user_not_found", errors.Code(err))
+	ass.True(errors.IsCategory(err, errors.NotFound))
+	ass.False(errors.IsCategory(io.EOF, errors.NotFound))
+
+	sentinel, ok := errors.Lookup("test.user_not_found")
+	ass.True(ok)
+	ass.True(errors.Is(errUserNotFound.WithDetail("uid=2"), sentinel))
+	ass.Equal(errors.NotFound, errors.ParseCategory("not_found"))
+
+	ass.Panics(func() {
+		errors.Define("test.user_not_found", errors.Internal, "duplicate")
+	})
+}
@@ -5,20 +5,146 @@ import (
 	"io"
 )
 
-type wrapError struct {
-	msg   string
-	cause error
+type (
+	fundamental struct {
+		msg string
+		*stack
+	}
+
+	withStack struct {
+		error
+		*stack
+	}
+
+	wrapError struct {
+		msg   string
+		cause error
+		*stack
+	}
+
+	// formatError 错误链中已有调用栈时 Errorf 的返回值，不再记录调用栈
+	formatError struct {
+		error
+	}
+)
+
+// New 创建错误并记录调用栈
+func New(text string) error {
+	return &fundamental{
+		msg:   text,
+		stack: callers(),
+	}
+}
+
+// Errorf 格式化创建错误，支持 %w，错误链中没有调用栈时记录调用栈
+func Errorf(format string, args ...interface{}) error {
+	err := fmt.Errorf(format, args...)
+	if hasStack(Unwrap(err)) {
+		return &formatError{error: err}
+	}
+	return &withStack{
+		error: err,
+		stack: callers(),
+	}
+}
+
+// WithStack 为 err 附加调用栈，错误链中已有调用栈时原样返回
+func WithStack(err error) error {
+	if err == nil || hasStack(err) {
+		return err
+	}
+	return &withStack{
+		error: err,
+		stack: callers(),
+	}
 }
 
+// Wrap 以 msg 包装 err，错误链中没有调用栈时记录调用栈
 func Wrap(err error, msg string) error {
 	if err == nil {
 		return nil
 	}
 
-	return &wrapError{
+	w := &wrapError{
 		cause: err,
 		msg:   msg,
 	}
+	if !hasStack(err) {
+		w.stack = callers()
+	}
+	return w
+}
+
+// Wrapf 以格式化信息包装 err
+func Wrapf(err error, format string, args ...interface{}) error {
+	if err == nil {
+		return nil
+	}
+
+	w := &wrapError{
+		cause: err,
+		msg:   fmt.Sprintf(format, args...),
+	}
+	if !hasStack(err) {
+		w.stack = callers()
+	}
+	return w
+}
+
+func (f *fundamental) Error() string { return f.msg }
+
+func (f *fundamental) Format(s fmt.State, verb rune) {
+	switch verb {
+	case 'v':
+		if s.Flag('+') {
+			_, _ = io.WriteString(s, f.msg)
+			f.stack.Format(s, verb)
+			return
+		}
+		fallthrough
+	case 's':
+		_, _ = io.WriteString(s, f.msg)
+	case 'q':
+		_, _ = fmt.Fprintf(s, "%q", f.msg)
+	}
+}
+
+func (w *withStack) Unwrap() error { return w.error }
+func (w *withStack) Cause() error  { return w.error }
+
+func (w *withStack) Format(s fmt.State, verb rune) {
+	switch verb {
+	case 'v':
+		if s.Flag('+') {
+			_, _ = fmt.Fprintf(s, "%+v", w.error)
+			w.stack.Format(s, verb)
+			return
+		}
+		fallthrough
+	case 's':
+		_, _ = io.WriteString(s, w.Error())
+	case 'q':
+		_, _ = fmt.Fprintf(s, "%q", w.Error())
+	}
+}
+
+func (f *formatError) Unwrap() error { return f.error }
+func (f *formatError) Cause() error  { return f.error }
+
+func (f *formatError) Format(s fmt.State, verb rune) {
+	switch verb {
+	case 'v':
+		if s.Flag('+') {
+			_, _ = fmt.Fprintf(s, "%+v\n", Unwrap(f.error))
+			_, _ = io.WriteString(s, f.Error())
+			return
+		}
+		fallthrough
+	case 's':
+		_, _ = io.WriteString(s, f.Error())
+	case 'q':
+		_, _ = fmt.Fprintf(s, "%q", f.Error())
+	}
 }
 
 func (w *wrapError) Error() string { return w.msg + ": " + w.cause.Error() }
@@ -31,6 +157,7 @@ func (w *wrapError) Format(s fmt.State, verb rune) {
 		if s.Flag('+') {
 			_, _ = fmt.Fprintf(s, "%+v\n", w.Cause())
 			_, _ = io.WriteString(s, w.msg)
+			w.stack.Format(s, verb)
 			return
 		}
 		fallthrough
@@ -0,0 +1,86 @@
+package errors
+
+import (
+	"fmt"
+	"io"
+)
+
+// BadKey With 中无法配对的值使用的 key
+const BadKey = "!BADKEY"
+
+type (
+	field struct {
+		key   string
+		value interface{}
+	}
+
+	fieldsError struct {
+		cause  error
+		fields []field
+	}
+)
+
+// With 为 err 附加 key-value 字段，kv 按 key, value 交替传入
+// 字段不会出现在 Error() 中，通过 Fields 提取
+func With(err error, kv ...interface{}) error {
+	if err == nil {
+		return nil
+	}
+
+	fields := make([]field, 0, (len(kv)+1)/2)
+	for i := 0; i < len(kv); i += 2 {
+		if i+1 >= len(kv) {
+			fields = append(fields, field{key: BadKey, value: kv[i]})
+			break
+		}
+		key, ok := kv[i].(string)
+		if !ok {
+			key = fmt.Sprint(kv[i])
+		}
+		fields = append(fields, field{key: key, value: kv[i+1]})
+	}
+	return &fieldsError{cause: err, fields: fields}
+}
+
+// Fields 提取错误链中所有字段，同名字段以外层为准
+func Fields(err error) map[string]interface{} {
+	var result map[string]interface{}
+	for err != nil {
+		if fe, ok := err.(*fieldsError); ok {
+			if result == nil {
+				result = make(map[string]interface{}, len(fe.fields))
+			}
+			for _, f := range fe.fields {
+				if _, exist := result[f.key]; !exist {
+					result[f.key] = f.value
+				}
+			}
+		}
+		err = Unwrap(err)
+	}
+	return result
+}
+
+func (f *fieldsError) Error() string { return f.cause.Error() }
+func (f *fieldsError) Unwrap() error { return f.cause }
+func (f *fieldsError) Cause() error  { return f.cause }
+
+// Format %+v 时在错误详情后输出字段
+func (f *fieldsError) Format(s fmt.State, verb rune) {
+	switch verb {
+	case 'v':
+		if s.Flag('+') {
+			_, _ = fmt.Fprintf(s, "%+v\n", f.cause)
+			for i, fd := range f.fields {
+				if i > 0 {
+					_, _ = io.WriteString(s, " ")
+				}
+				_, _ = fmt.Fprintf(s, "%s=%v", fd.key, fd.value)
+			}
+			return
+		}
+		fallthrough
+	case 's', 'q':
+		_, _ = io.WriteString(s, f.Error())
+	}
+}
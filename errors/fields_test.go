@@ -0,0 +1,28 @@
+package errors_test
+
+import (
+	"io"
+	"testing"
+
+	"github.com/sanbsy/gopkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFields(t *testing.T) {
+	ass := assert.New(t)
+
+	err := errors.With(io.EOF, "bucket", "users", "key", "k1")
+	err = errors.Wrap(err, "get user")
+	err = errors.With(err, "key", "k2", "dangling")
+
+	ass.Equal("get user: EOF", err.Error())
+	ass.True(errors.Is(err, io.EOF))
+	ass.Equal(map[string]interface{}{
+		"bucket":      "users",
+		"key":         "k2",
+		errors.BadKey: "dangling",
+	}, errors.Fields(err))
+
+	ass.Nil(errors.Fields(io.EOF))
+	ass.Nil(errors.With(nil, "key", "value"))
+}
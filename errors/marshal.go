@@ -0,0 +1,446 @@
+package errors
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Encoded 错误的可序列化形式，每个节点对应错误链中的一层
+type Encoded struct {
+	// Message 当前层的信息，不包含 cause
+	Message  string                 `json:"message"`
+	Code     string                 `json:"code,omitempty"`
+	Category string                 `json:"category,omitempty"`
+	Detail   string                 `json:"detail,omitempty"`
+	Fields   map[string]interface{} `json:"fields,omitempty"`
+	Stack    []string               `json:"stack,omitempty"`
+	// Opaque Message 已包含 cause 的信息，还原时不再拼接
+	Opaque bool       `json:"opaque,omitempty"`
+	Errors []*Encoded `json:"errors,omitempty"`
+	Cause  *Encoded   `json:"cause,omitempty"`
+}
+
+// Encode 将错误链转换为 Encoded，includeStack 为 false 时不包含调用栈
+func Encode(err error, includeStack bool) *Encoded {
+	if err == nil {
+		return nil
+	}
+
+	var node *Encoded
+	switch e := err.(type) {
+	case *fundamental:
+		node = &Encoded{Message: e.msg}
+	case *withStack:
+		node = Encode(e.error, includeStack)
+	case *formatError:
+		node = Encode(e.error, includeStack)
+	case *wrapError:
+		node = &Encoded{Message: e.msg, Cause: Encode(e.cause, includeStack)}
+	case *CodedError:
+		node = &Encoded{
+			Message:  e.message,
+			Code:     e.code,
+			Category: e.category.String(),
+			Detail:   e.detail,
+			Cause:    Encode(e.cause, includeStack),
+		}
+	case *fieldsError:
+		node = Encode(e.cause, includeStack)
+		if node.Fields == nil {
+			node.Fields = make(map[string]interface{}, len(e.fields))
+		}
+		for _, f := range e.fields {
+			node.Fields[f.key] = encodeValue(f.value)
+		}
+	case *MultiError:
+		node = &Encoded{Message: e.Error(), Opaque: true}
+		for _, child := range e.errs {
+			node.Errors = append(node.Errors, Encode(child, includeStack))
+		}
+	case *remoteStack:
+		node = Encode(e.error, includeStack)
+		if includeStack && len(node.Stack) == 0 {
+			node.Stack = e.frames
+		}
+		return node
+	default:
+		node = &Encoded{Message: err.Error()}
+		if cause := Unwrap(err); cause != nil {
+			node.Cause = Encode(cause, includeStack)
+			if own := strings.TrimSuffix(node.Message, ": "+cause.Error()); own != node.Message {
+				node.Message = own
+			} else {
+				node.Opaque = true
+			}
+		}
+	}
+
+	if st, ok := err.(stackTracer); includeStack && ok && len(node.Stack) == 0 {
+		for _, f := range st.StackTrace() {
+			node.Stack = append(node.Stack, fmt.Sprintf("%+v", f))
+		}
+	}
+	return node
+}
+
+func encodeValue(v interface{}) interface{} {
+	if _, err := json.Marshal(v); err != nil {
+		return fmt.Sprint(v)
+	}
+	return v
+}
+
+// Decode 还原错误链
+// 带错误码的节点还原为 *CodedError，与同错误码的哨兵错误 errors.Is 判定相等；
+// 与 RegisterSentinel 注册的哨兵错误信息一致的末端节点还原为哨兵错误本身
+func (e *Encoded) Decode() error {
+	if e == nil {
+		return nil
+	}
+
+	var err error
+	cause := e.Cause.Decode()
+	switch {
+	case e.Code != "":
+		err = &CodedError{
+			code:     e.Code,
+			category: ParseCategory(e.Category),
+			message:  e.Message,
+			detail:   e.Detail,
+			cause:    cause,
+		}
+	case len(e.Errors) > 0:
+		m := &MultiError{}
+		for _, child := range e.Errors {
+			m.errs = append(m.errs, child.Decode())
+		}
+		err = m
+	case cause == nil:
+		if err = lookupSentinel(e.Message); err == nil {
+			err = &remoteError{msg: e.Message, opaque: true}
+		}
+	default:
+		err = &remoteError{msg: e.Message, cause: cause, opaque: e.Opaque}
+	}
+
+	if len(e.Stack) > 0 {
+		err = &remoteStack{error: err, frames: e.Stack}
+	}
+	if len(e.Fields) > 0 {
+		fe := &fieldsError{cause: err, fields: make([]field, 0, len(e.Fields))}
+		for key, value := range e.Fields {
+			fe.fields = append(fe.fields, field{key: key, value: value})
+		}
+		err = fe
+	}
+	return err
+}
+
+func lookupSentinel(msg string) error {
+	sentinelMu.RLock()
+	defer sentinelMu.RUnlock()
+	for _, s := range sentinels {
+		if s.err.Error() == msg {
+			return s.err
+		}
+	}
+	return nil
+}
+
+// Marshal 将错误序列化为 JSON，包含调用栈
+func Marshal(err error) ([]byte, error) {
+	return json.Marshal(Encode(err, true))
+}
+
+// Unmarshal 解析 Marshal 的结果，通过 Encoded.Decode 还原错误；data 为 JSON null 时返回 nil
+func Unmarshal(data []byte) (*Encoded, error) {
+	var e *Encoded
+	if err := json.Unmarshal(data, &e); err != nil {
+		return nil, err
+	}
+	if e.depth() > maxDecodeDepth {
+		return nil, errTooDeep
+	}
+	return e, nil
+}
+
+// depth 错误链的最大嵌套层数
+func (e *Encoded) depth() int {
+	if e == nil {
+		return 0
+	}
+	max := e.Cause.depth()
+	for _, child := range e.Errors {
+		if d := child.depth(); d > max {
+			max = d
+		}
+	}
+	return max + 1
+}
+
+type (
+	// remoteError 反序列化得到的普通错误
+	remoteError struct {
+		msg    string
+		cause  error
+		opaque bool
+	}
+
+	// remoteStack 反序列化得到的调用栈
+	remoteStack struct {
+		error
+		frames []string
+	}
+)
+
+func (r *remoteError) Error() string {
+	if r.opaque || r.cause == nil {
+		return r.msg
+	}
+	return r.msg + ": " + r.cause.Error()
+}
+
+func (r *remoteError) Unwrap() error { return r.cause }
+
+func (r *remoteError) Format(s fmt.State, verb rune) {
+	switch verb {
+	case 'v':
+		if s.Flag('+') && r.cause != nil {
+			_, _ = fmt.Fprintf(s, "%+v\n", r.cause)
+			_, _ = io.WriteString(s, r.msg)
+			return
+		}
+		fallthrough
+	case 's', 'q':
+		_, _ = io.WriteString(s, r.Error())
+	}
+}
+
+func (r *remoteStack) Unwrap() error { return r.error }
+
+// RemoteStack 获取反序列化错误中携带的调用栈
+func (r *remoteStack) RemoteStack() []string { return r.frames }
+
+func (r *remoteStack) Format(s fmt.State, verb rune) {
+	switch verb {
+	case 'v':
+		if s.Flag('+') {
+			_, _ = fmt.Fprintf(s, "%+v", r.error)
+			for _, f := range r.frames {
+				_, _ = io.WriteString(s, "\n"+f)
+			}
+			return
+		}
+		fallthrough
+	case 's', 'q':
+		_, _ = io.WriteString(s, r.Error())
+	}
+}
+
+// RemoteStack 获取错误链中反序列化得到的调用栈，没有时返回 nil
+func RemoteStack(err error) []string {
+	var r *remoteStack
+	if As(err, &r) {
+		return r.frames
+	}
+	return nil
+}
+
+const binaryVersion = 1
+
+const (
+	flagCode = 1 << iota
+	flagDetail
+	flagFields
+	flagStack
+	flagOpaque
+	flagErrors
+	flagCause
+)
+
+// MarshalBinary 将错误序列化为紧凑的二进制格式，包含调用栈
+func MarshalBinary(err error) ([]byte, error) {
+	buf := []byte{binaryVersion}
+	if err == nil {
+		return buf, nil
+	}
+	return appendEncoded(buf, Encode(err, true))
+}
+
+// UnmarshalBinary 解析 MarshalBinary 的结果，通过 Encoded.Decode 还原错误；err 为 nil 时返回 nil
+func UnmarshalBinary(data []byte) (*Encoded, error) {
+	if len(data) == 0 || data[0] != binaryVersion {
+		return nil, New("errors: unsupported binary version")
+	}
+	if len(data) == 1 {
+		return nil, nil
+	}
+	d := &binaryDecoder{data: data[1:]}
+	e := d.encoded(0)
+	if d.err != nil {
+		return nil, d.err
+	}
+	return e, nil
+}
+
+func appendEncoded(buf []byte, e *Encoded) ([]byte, error) {
+	var flags byte
+	if e.Code != "" {
+		flags |= flagCode
+	}
+	if e.Detail != "" {
+		flags |= flagDetail
+	}
+	if len(e.Fields) > 0 {
+		flags |= flagFields
+	}
+	if len(e.Stack) > 0 {
+		flags |= flagStack
+	}
+	if e.Opaque {
+		flags |= flagOpaque
+	}
+	if len(e.Errors) > 0 {
+		flags |= flagErrors
+	}
+	if e.Cause != nil {
+		flags |= flagCause
+	}
+
+	buf = append(buf, flags)
+	buf = appendString(buf, e.Message)
+	if flags&flagCode != 0 {
+		buf = appendString(buf, e.Code)
+		buf = appendString(buf, e.Category)
+	}
+	if flags&flagDetail != 0 {
+		buf = appendString(buf, e.Detail)
+	}
+	if flags&flagFields != 0 {
+		fields, err := json.Marshal(e.Fields)
+		if err != nil {
+			return nil, err
+		}
+		buf = appendString(buf, string(fields))
+	}
+	if flags&flagStack != 0 {
+		buf = appendUvarint(buf, uint64(len(e.Stack)))
+		for _, f := range e.Stack {
+			buf = appendString(buf, f)
+		}
+	}
+
+	var err error
+	if flags&flagErrors != 0 {
+		buf = appendUvarint(buf, uint64(len(e.Errors)))
+		for _, child := range e.Errors {
+			if buf, err = appendEncoded(buf, child); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if flags&flagCause != 0 {
+		return appendEncoded(buf, e.Cause)
+	}
+	return buf, nil
+}
+
+func appendUvarint(buf []byte, v uint64) []byte {
+	var tmp [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(tmp[:], v)
+	return append(buf, tmp[:n]...)
+}
+
+func appendString(buf []byte, s string) []byte {
+	buf = appendUvarint(buf, uint64(len(s)))
+	return append(buf, s...)
+}
+
+// maxDecodeDepth 反序列化时错误链允许的最大嵌套层数，避免恶意数据耗尽调用栈
+const maxDecodeDepth = 256
+
+var (
+	errCorruptBinary = New("errors: corrupt binary data")
+	errTooDeep       = New("errors: encoded error nests too deeply")
+)
+
+type binaryDecoder struct {
+	data []byte
+	err  error
+}
+
+func (d *binaryDecoder) uvarint() uint64 {
+	if d.err != nil {
+		return 0
+	}
+	v, n := binary.Uvarint(d.data)
+	if n <= 0 {
+		d.err = errCorruptBinary
+		return 0
+	}
+	d.data = d.data[n:]
+	return v
+}
+
+func (d *binaryDecoder) string() string {
+	n := d.uvarint()
+	if d.err != nil {
+		return ""
+	}
+	if n > uint64(len(d.data)) {
+		d.err = errCorruptBinary
+		return ""
+	}
+	s := string(d.data[:n])
+	d.data = d.data[n:]
+	return s
+}
+
+func (d *binaryDecoder) encoded(depth int) *Encoded {
+	if d.err != nil {
+		return nil
+	}
+	if depth >= maxDecodeDepth {
+		d.err = errTooDeep
+		return nil
+	}
+	if len(d.data) == 0 {
+		d.err = errCorruptBinary
+		return nil
+	}
+	flags := d.data[0]
+	d.data = d.data[1:]
+
+	e := &Encoded{Message: d.string(), Opaque: flags&flagOpaque != 0}
+	if flags&flagCode != 0 {
+		e.Code = d.string()
+		e.Category = d.string()
+	}
+	if flags&flagDetail != 0 {
+		e.Detail = d.string()
+	}
+	if flags&flagFields != 0 {
+		if fields := d.string(); d.err == nil {
+			d.err = json.Unmarshal([]byte(fields), &e.Fields)
+		}
+	}
+	if flags&flagStack != 0 {
+		n := d.uvarint()
+		for i := uint64(0); i < n && d.err == nil; i++ {
+			e.Stack = append(e.Stack, d.string())
+		}
+	}
+	if flags&flagErrors != 0 {
+		n := d.uvarint()
+		for i := uint64(0); i < n && d.err == nil; i++ {
+			e.Errors = append(e.Errors, d.encoded(depth+1))
+		}
+	}
+	if flags&flagCause != 0 {
+		e.Cause = d.encoded(depth + 1)
+	}
+	return e
+}
@@ -0,0 +1,92 @@
+package errors_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/sanbsy/gopkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMarshal(t *testing.T) {
+	r := require.New(t)
+
+	origin := errors.With(
+		errors.Wrap(errUserNotFound.Wrap(context.DeadlineExceeded, "uid=1"), "load user"),
+		"uid", 1,
+	)
+
+	for name, codec := range map[string]struct {
+		marshal   func(error) ([]byte, error)
+		unmarshal func([]byte) (*errors.Encoded, error)
+	}{
+		"json":   {errors.Marshal, errors.Unmarshal},
+		"binary": {errors.MarshalBinary, errors.UnmarshalBinary},
+	} {
+		t.Run(name, func(t *testing.T) {
+			data, err := codec.marshal(origin)
+			r.Nil(err)
+
+			e, err := codec.unmarshal(data)
+			r.Nil(err)
+			decoded := e.Decode()
+
+			ass := assert.New(t)
+			ass.Equal(origin.Error(), decoded.Error())
+			ass.True(errors.Is(decoded, errUserNotFound))
+			ass.True(errors.Is(decoded, context.DeadlineExceeded))
+			ass.Equal("test.user_not_found", errors.Code(decoded))
+			ass.Equal(errors.NotFound, errors.CategoryOf(decoded))
+			ass.Equal(map[string]interface{}{"uid": float64(1)}, errors.Fields(decoded))
+			ass.NotEmpty(errors.RemoteStack(decoded))
+			ass.Contains(fmt.Sprintf("%+v", decoded), "TestMarshal")
+		})
+	}
+}
+
+func TestMarshal_Opaque(t *testing.T) {
+	ass := assert.New(t)
+
+	origin := fmt.Errorf("query failed (%w)", errors.Join(context.Canceled, errUserNotFound))
+	data, err := errors.Marshal(origin)
+	ass.Nil(err)
+
+	e, err := errors.Unmarshal(data)
+	ass.Nil(err)
+	decoded := e.Decode()
+	ass.Equal(origin.Error(), decoded.Error())
+	ass.True(errors.Is(decoded, context.Canceled))
+	ass.True(errors.Is(decoded, errUserNotFound))
+
+	e, err = errors.Unmarshal([]byte("null"))
+	ass.Nil(err)
+	ass.Nil(e)
+	ass.Nil(e.Decode())
+
+	_, err = errors.UnmarshalBinary([]byte{1, 0, 10})
+	ass.NotNil(err)
+}
+
+func TestUnmarshal_Depth(t *testing.T) {
+	ass := assert.New(t)
+
+	err := errors.New("root")
+	for i := 0; i < 300; i++ {
+		err = errors.Wrap(err, "wrap")
+	}
+	data, e := errors.Marshal(err)
+	ass.Nil(e)
+	_, e = errors.Unmarshal(data)
+	ass.NotNil(e)
+
+	// 每层仅有 flagCause 与空信息，伪造深度嵌套的二进制数据
+	data = []byte{1}
+	for i := 0; i < 100000; i++ {
+		data = append(data, 1<<6, 0)
+	}
+	data = append(data, 0, 0)
+	_, e = errors.UnmarshalBinary(data)
+	ass.NotNil(e)
+}
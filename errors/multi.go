@@ -0,0 +1,147 @@
+package errors
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// FormatFunc 自定义 MultiError 的错误信息格式
+type FormatFunc func(errs []error) string
+
+// MultiError 聚合多个错误，非并发安全
+type MultiError struct {
+	errs []error
+	// Formatter 错误信息格式，为空时使用 ListFormatFunc
+	Formatter FormatFunc
+}
+
+// Append 将 errs 追加到 err 中，返回 *MultiError
+// err 为 *MultiError 时直接追加，为 nil 的 *MultiError 时创建新的 *MultiError，
+// nil 错误会被忽略，errs 中的 *MultiError 会被展开
+func Append(err error, errs ...error) *MultiError {
+	m, ok := err.(*MultiError)
+	if !ok && err != nil {
+		m = &MultiError{errs: []error{err}}
+	}
+	return m.Append(errs...)
+}
+
+// Join 聚合多个错误，全部为 nil 时返回 nil
+func Join(errs ...error) error {
+	return (&MultiError{}).Append(errs...).ErrorOrNil()
+}
+
+// Append 追加错误，nil 错误会被忽略，*MultiError 会被展开
+// m 为 nil 时创建新的 *MultiError
+func (m *MultiError) Append(errs ...error) *MultiError {
+	if m == nil {
+		m = &MultiError{}
+	}
+	for _, err := range errs {
+		switch e := err.(type) {
+		case nil:
+		case *MultiError:
+			if e != nil {
+				m.errs = append(m.errs, e.errs...)
+			}
+		default:
+			m.errs = append(m.errs, err)
+		}
+	}
+	return m
+}
+
+// ErrorOrNil 没有错误时返回 nil，否则返回自身
+func (m *MultiError) ErrorOrNil() error {
+	if m == nil || len(m.errs) == 0 {
+		return nil
+	}
+	return m
+}
+
+// Errors 获取所有错误
+func (m *MultiError) Errors() []error {
+	if m == nil {
+		return nil
+	}
+	return m.errs
+}
+
+// Len 错误数量
+func (m *MultiError) Len() int {
+	if m == nil {
+		return 0
+	}
+	return len(m.errs)
+}
+
+func (m *MultiError) Error() string {
+	if m == nil {
+		return ""
+	}
+	if m.Formatter != nil {
+		return m.Formatter(m.errs)
+	}
+	return ListFormatFunc(m.errs)
+}
+
+// Is 任意一个错误与 target 匹配即返回 true
+func (m *MultiError) Is(target error) bool {
+	for _, err := range m.Errors() {
+		if Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// As 在所有错误中查找第一个可以赋值给 target 的错误
+func (m *MultiError) As(target interface{}) bool {
+	for _, err := range m.Errors() {
+		if As(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// Format %+v 时逐个输出每个错误的详细信息
+func (m *MultiError) Format(s fmt.State, verb rune) {
+	switch verb {
+	case 'v':
+		if s.Flag('+') {
+			_, _ = io.WriteString(s, strconv.Itoa(m.Len())+" errors occurred:")
+			for i, err := range m.Errors() {
+				_, _ = fmt.Fprintf(s, "\n[%d] %+v", i, err)
+			}
+			return
+		}
+		fallthrough
+	case 's', 'q':
+		_, _ = io.WriteString(s, m.Error())
+	}
+}
+
+// ListFormatFunc 默认格式，单个错误时直接输出，多个错误时逐行列出
+func ListFormatFunc(errs []error) string {
+	if len(errs) == 1 {
+		return errs[0].Error()
+	}
+
+	points := make([]string, len(errs))
+	for i, err := range errs {
+		points[i] = "* " + err.Error()
+	}
+	return strconv.Itoa(len(errs)) + " errors occurred:\n\t" + strings.Join(points, "\n\t")
+}
+
+// InlineFormatFunc 以 "; " 分隔，在一行中输出所有错误
+func InlineFormatFunc(errs []error) string {
+	msgs := make([]string, len(errs))
+	for i, err := range errs {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
@@ -0,0 +1,62 @@
+package errors_test
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"testing"
+
+	"github.com/sanbsy/gopkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMultiError(t *testing.T) {
+	ass := assert.New(t)
+
+	var merr *errors.MultiError
+	ass.Nil(merr.ErrorOrNil())
+	ass.Nil(errors.Join(nil, nil))
+
+	merr = errors.Append(nil, io.EOF, nil)
+	ass.Equal("EOF", merr.Error())
+
+	pathErr := &os.PathError{Op: "open", Path: "/tmp/x", Err: os.ErrNotExist}
+	merr = errors.Append(merr, errors.Wrap(pathErr, "load"))
+	ass.Equal(2, merr.Len())
+	ass.Equal("2 errors occurred:\n\t* EOF\n\t* load: open /tmp/x: file does not exist", merr.Error())
+
+	err := merr.ErrorOrNil()
+	ass.True(errors.Is(err, io.EOF))
+	ass.True(errors.Is(err, os.ErrNotExist))
+
+	var target *os.PathError
+	ass.True(errors.As(err, &target))
+	ass.Equal("/tmp/x", target.Path)
+
+	merr.Formatter = errors.InlineFormatFunc
+	ass.Equal("EOF; load: open /tmp/x: file does not exist", merr.Error())
+	ass.Contains(fmt.Sprintf("%+v", merr), "[1] open /tmp/x: file does not exist\nload")
+
+	joined := errors.Append(io.ErrUnexpectedEOF, merr)
+	ass.Len(joined.Errors(), 3)
+}
+
+func TestMultiError_Nil(t *testing.T) {
+	ass := assert.New(t)
+
+	var m *errors.MultiError
+	ass.Nil(m.ErrorOrNil())
+	ass.Equal("", m.Error())
+	ass.False(m.Is(io.EOF))
+	ass.Equal("0 errors occurred:", fmt.Sprintf("%+v", m))
+
+	m = errors.Append(m, io.EOF)
+	ass.Equal(1, m.Len())
+	m = errors.Append(m, nil, os.ErrNotExist)
+	ass.Equal(2, m.Len())
+	ass.True(errors.Is(m, os.ErrNotExist))
+
+	var empty *errors.MultiError
+	ass.Nil(errors.Append(empty).ErrorOrNil())
+	ass.Equal(1, empty.Append(io.EOF).Len())
+}
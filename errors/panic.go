@@ -0,0 +1,83 @@
+package errors
+
+import (
+	"fmt"
+	"io"
+)
+
+// PanicError 由 panic 转换而来的错误
+type PanicError struct {
+	// Value panic 的参数
+	Value interface{}
+	*stack
+}
+
+// NewPanicError 以 panic 的参数创建 PanicError，记录调用栈
+func NewPanicError(value interface{}) *PanicError {
+	return &PanicError{
+		Value: value,
+		stack: panicCallers(),
+	}
+}
+
+func (p *PanicError) Error() string {
+	return fmt.Sprintf("panic: %v", p.Value)
+}
+
+// Unwrap panic 的参数为 error 时返回该 error
+func (p *PanicError) Unwrap() error {
+	if err, ok := p.Value.(error); ok {
+		return err
+	}
+	return nil
+}
+
+func (p *PanicError) Format(s fmt.State, verb rune) {
+	switch verb {
+	case 'v':
+		if s.Flag('+') {
+			_, _ = io.WriteString(s, p.Error())
+			p.stack.Format(s, verb)
+			return
+		}
+		fallthrough
+	case 's', 'q':
+		_, _ = io.WriteString(s, p.Error())
+	}
+}
+
+// Recover 将 panic 转换为 *PanicError 并写入 errp，必须直接通过 defer 调用
+//
+//	func Do() (err error) {
+//		defer errors.Recover(&err)
+//		...
+//	}
+func Recover(errp *error) {
+	if r := recover(); r != nil {
+		*errp = &PanicError{
+			Value: r,
+			stack: panicCallers(),
+		}
+	}
+}
+
+// Try 执行 fn，fn 中的 panic 会被转换为 *PanicError 返回
+func Try(fn func() error) (err error) {
+	defer Recover(&err)
+	return fn()
+}
+
+// panicCallers 捕获调用栈，从触发 panic 的函数开始记录
+func panicCallers() *stack {
+	s := callers()
+	if s == nil {
+		return nil
+	}
+	for i, pc := range *s {
+		if Frame(pc).Func() == "runtime.gopanic" {
+			*s = (*s)[i+1:]
+			break
+		}
+	}
+	return s
+}
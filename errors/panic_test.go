@@ -0,0 +1,44 @@
+package errors_test
+
+import (
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/sanbsy/gopkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func mustPositive(n int) (err error) {
+	defer errors.Recover(&err)
+	if n <= 0 {
+		panic(fmt.Sprintf("invalid number %d", n))
+	}
+	return nil
+}
+
+func TestRecover(t *testing.T) {
+	ass := assert.New(t)
+
+	ass.Nil(mustPositive(1))
+
+	err := mustPositive(0)
+	var perr *errors.PanicError
+	ass.True(errors.As(err, &perr))
+	ass.Equal("invalid number 0", perr.Value)
+	ass.Equal("panic: invalid number 0", err.Error())
+	ass.Contains(errors.Stack(err)[0].Func(), "mustPositive")
+}
+
+func TestTry(t *testing.T) {
+	ass := assert.New(t)
+
+	err := errors.Try(func() error {
+		panic(io.EOF)
+	})
+	ass.True(errors.Is(err, io.EOF))
+
+	ass.Equal(io.ErrUnexpectedEOF, errors.Try(func() error {
+		return io.ErrUnexpectedEOF
+	}))
+}
@@ -0,0 +1,55 @@
+package errors
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// ProblemContentType RFC 7807 响应类型
+const ProblemContentType = "application/problem+json"
+
+// Problem RFC 7807 problem details
+// 只包含可展示给用户的信息，内部详情与错误链不会输出
+type Problem struct {
+	Type          string `json:"type"`
+	Title         string `json:"title"`
+	Status        int    `json:"status"`
+	Detail        string `json:"detail,omitempty"`
+	Instance      string `json:"instance,omitempty"`
+	Code          string `json:"code,omitempty"`
+	CorrelationID string `json:"correlation_id,omitempty"`
+}
+
+// NewProblem 根据错误创建 Problem，correlationID 用于关联日志
+func NewProblem(err error, correlationID string) *Problem {
+	status := HTTPStatus(err)
+	p := &Problem{
+		Type:          "about:blank",
+		Title:         http.StatusText(status),
+		Status:        status,
+		CorrelationID: correlationID,
+	}
+	// 非标准状态码（如 499）没有对应的描述，使用错误分类名称
+	if p.Title == "" {
+		p.Title = CategoryOf(err).String()
+	}
+
+	var c *CodedError
+	if As(err, &c) {
+		p.Code = c.Code()
+		p.Detail = c.Message()
+	}
+	return p
+}
+
+// WriteTo 以 application/problem+json 输出到 http.ResponseWriter
+func (p *Problem) WriteTo(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", ProblemContentType)
+	w.WriteHeader(p.Status)
+	return json.NewEncoder(w).Encode(p)
+}
+
+// WriteProblem 将错误以 RFC 7807 格式输出
+func WriteProblem(w http.ResponseWriter, err error, correlationID string) error {
+	return NewProblem(err, correlationID).WriteTo(w)
+}
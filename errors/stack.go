@@ -0,0 +1,172 @@
+package errors
+
+import (
+	"fmt"
+	"io"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync/atomic"
+)
+
+const maxStackDepth = 32
+
+// 是否捕获调用栈，1 为开启
+var stackCapture int32 = 1
+
+// SetStackCapture 全局开启或关闭调用栈捕获
+// 在热点路径上关闭后，New/Wrap 等函数不再记录调用栈
+func SetStackCapture(enable bool) {
+	var v int32
+	if enable {
+		v = 1
+	}
+	atomic.StoreInt32(&stackCapture, v)
+}
+
+// Frame 调用栈中的一帧
+type Frame uintptr
+
+func (f Frame) pc() uintptr { return uintptr(f) - 1 }
+
+// Func 获取函数名
+func (f Frame) Func() string {
+	fn := runtime.FuncForPC(f.pc())
+	if fn == nil {
+		return "unknown"
+	}
+	return fn.Name()
+}
+
+// File 获取文件路径
+func (f Frame) File() string {
+	fn := runtime.FuncForPC(f.pc())
+	if fn == nil {
+		return "unknown"
+	}
+	file, _ := fn.FileLine(f.pc())
+	return file
+}
+
+// Line 获取行号
+func (f Frame) Line() int {
+	fn := runtime.FuncForPC(f.pc())
+	if fn == nil {
+		return 0
+	}
+	_, line := fn.FileLine(f.pc())
+	return line
+}
+
+// Format 支持以下格式
+//
+//	%s    文件名
+//	%d    行号
+//	%n    函数名
+//	%v    等同于 %s:%d
+//	%+v   函数名与完整文件路径:行号
+func (f Frame) Format(s fmt.State, verb rune) {
+	switch verb {
+	case 's':
+		file := f.File()
+		if !s.Flag('+') {
+			file = file[strings.LastIndexByte(file, '/')+1:]
+		}
+		_, _ = io.WriteString(s, file)
+	case 'd':
+		_, _ = io.WriteString(s, strconv.Itoa(f.Line()))
+	case 'n':
+		name := f.Func()
+		_, _ = io.WriteString(s, name[strings.LastIndexByte(name, '/')+1:])
+	case 'v':
+		if s.Flag('+') {
+			_, _ = io.WriteString(s, f.Func())
+			_, _ = io.WriteString(s, "\n\t")
+			_, _ = io.WriteString(s, f.File())
+		} else {
+			f.Format(s, 's')
+		}
+		_, _ = io.WriteString(s, ":")
+		f.Format(s, 'd')
+	}
+}
+
+// StackTrace 调用栈，由近及远排列
+type StackTrace []Frame
+
+// Format %+v 时逐行输出每一帧，其余格式输出帧列表
+func (st StackTrace) Format(s fmt.State, verb rune) {
+	switch verb {
+	case 'v':
+		if s.Flag('+') {
+			for _, f := range st {
+				_, _ = io.WriteString(s, "\n")
+				f.Format(s, verb)
+			}
+			return
+		}
+		fallthrough
+	case 's':
+		_, _ = io.WriteString(s, "[")
+		for i, f := range st {
+			if i > 0 {
+				_, _ = io.WriteString(s, " ")
+			}
+			f.Format(s, verb)
+		}
+		_, _ = io.WriteString(s, "]")
+	}
+}
+
+type stack []uintptr
+
+// StackTrace 获取调用栈
+func (s *stack) StackTrace() StackTrace {
+	if s == nil {
+		return nil
+	}
+	st := make(StackTrace, len(*s))
+	for i, pc := range *s {
+		st[i] = Frame(pc)
+	}
+	return st
+}
+
+func (s *stack) Format(st fmt.State, verb rune) {
+	if s != nil && verb == 'v' && st.Flag('+') {
+		s.StackTrace().Format(st, verb)
+	}
+}
+
+// callers 捕获调用栈，跳过 runtime.Callers、callers 以及调用 callers 的函数
+func callers() *stack {
+	if atomic.LoadInt32(&stackCapture) == 0 {
+		return nil
+	}
+	var pcs [maxStackDepth]uintptr
+	n := runtime.Callers(3, pcs[:])
+	st := stack(pcs[:n])
+	return &st
+}
+
+type stackTracer interface {
+	StackTrace() StackTrace
+}
+
+// Stack 获取错误链中记录的调用栈，未记录时返回 nil
+func Stack(err error) StackTrace {
+	for err != nil {
+		if st, ok := err.(stackTracer); ok {
+			if trace := st.StackTrace(); len(trace) > 0 {
+				return trace
+			}
+		}
+		err = Unwrap(err)
+	}
+	return nil
+}
+
+// hasStack 错误链中是否已记录调用栈
+func hasStack(err error) bool {
+	return Stack(err) != nil
+}
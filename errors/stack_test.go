@@ -0,0 +1,65 @@
+package errors_test
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/sanbsy/gopkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStack(t *testing.T) {
+	ass := assert.New(t)
+
+	err := errors.Wrap(errors.New("root"), "wrapped")
+	ass.Equal("wrapped: root", err.Error())
+
+	st := errors.Stack(err)
+	ass.NotEmpty(st)
+	ass.Equal("stack_test.go", fmt.Sprintf("%s", st[0]))
+	ass.Contains(st[0].Func(), "TestStack")
+
+	detail := fmt.Sprintf("%+v", err)
+	ass.Equal(1, strings.Count(detail, "errors_test.TestStack"), "stack should be captured once per chain")
+
+	wrapped := errors.Wrapf(io.EOF, "read %s", "file")
+	ass.True(errors.Is(wrapped, io.EOF))
+	ass.NotEmpty(errors.Stack(wrapped))
+
+	ass.True(errors.Is(errors.Errorf("read: %w", io.EOF), io.EOF))
+	ass.Nil(errors.WithStack(nil))
+}
+
+func TestErrorf_CauseWithStack(t *testing.T) {
+	ass := assert.New(t)
+
+	cause := errors.New("root")
+	err := errors.Errorf("load %s: %w", "user", cause)
+	ass.Equal("load user: root", err.Error())
+	ass.True(errors.Is(err, cause))
+	ass.Equal(errors.Stack(cause), errors.Stack(err))
+
+	detail := fmt.Sprintf("%+v", err)
+	ass.Equal(1, strings.Count(detail, "errors_test.TestErrorf_CauseWithStack"), detail)
+	ass.True(strings.HasSuffix(detail, "\nload user: root"), detail)
+	ass.Equal("load user: root", fmt.Sprintf("%v", err))
+	ass.Equal(`"load user: root"`, fmt.Sprintf("%q", err))
+
+	data, e := errors.Marshal(err)
+	ass.Nil(e)
+	encoded, e := errors.Unmarshal(data)
+	ass.Nil(e)
+	ass.Equal("load user", encoded.Message)
+	ass.Equal("load user: root", encoded.Decode().Error())
+}
+
+func TestSetStackCapture(t *testing.T) {
+	errors.SetStackCapture(false)
+	defer errors.SetStackCapture(true)
+
+	err := errors.WithStack(errors.New("no stack"))
+	assert.Nil(t, errors.Stack(err))
+	assert.Equal(t, "no stack", fmt.Sprintf("%+v", err))
+}
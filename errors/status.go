@@ -0,0 +1,124 @@
+package errors
+
+import (
+	"context"
+	"net/http"
+	"sync"
+)
+
+// 与 gRPC codes.Code 取值一致，避免引入 grpc 依赖
+const (
+	rpcOK                 uint32 = 0
+	rpcCanceled           uint32 = 1
+	rpcUnknown            uint32 = 2
+	rpcInvalidArgument    uint32 = 3
+	rpcDeadlineExceeded   uint32 = 4
+	rpcNotFound           uint32 = 5
+	rpcAlreadyExists      uint32 = 6
+	rpcPermissionDenied   uint32 = 7
+	rpcResourceExhausted  uint32 = 8
+	rpcFailedPrecondition uint32 = 9
+	rpcUnimplemented      uint32 = 12
+	rpcInternal           uint32 = 13
+	rpcUnavailable        uint32 = 14
+	rpcUnauthenticated    uint32 = 16
+)
+
+// StatusClientClosedRequest 客户端主动断开请求，非标准状态码（nginx 499）
+const StatusClientClosedRequest = 499
+
+var categoryStatus = [...]struct {
+	http int
+	rpc  uint32
+}{
+	Unknown:            {http.StatusInternalServerError, rpcUnknown},
+	InvalidArgument:    {http.StatusBadRequest, rpcInvalidArgument},
+	NotFound:           {http.StatusNotFound, rpcNotFound},
+	AlreadyExists:      {http.StatusConflict, rpcAlreadyExists},
+	PermissionDenied:   {http.StatusForbidden, rpcPermissionDenied},
+	Unauthenticated:    {http.StatusUnauthorized, rpcUnauthenticated},
+	ResourceExhausted:  {http.StatusTooManyRequests, rpcResourceExhausted},
+	FailedPrecondition: {http.StatusPreconditionFailed, rpcFailedPrecondition},
+	Canceled:           {StatusClientClosedRequest, rpcCanceled},
+	DeadlineExceeded:   {http.StatusGatewayTimeout, rpcDeadlineExceeded},
+	Unavailable:        {http.StatusServiceUnavailable, rpcUnavailable},
+	Unimplemented:      {http.StatusNotImplemented, rpcUnimplemented},
+	Internal:           {http.StatusInternalServerError, rpcInternal},
+}
+
+// HTTPStatus 错误分类对应的 HTTP 状态码
+func (c Category) HTTPStatus() int {
+	if int(c) < len(categoryStatus) {
+		return categoryStatus[c].http
+	}
+	return http.StatusInternalServerError
+}
+
+// GRPCCode 错误分类对应的 gRPC 状态码，可直接转换为 codes.Code
+func (c Category) GRPCCode() uint32 {
+	if int(c) < len(categoryStatus) {
+		return categoryStatus[c].rpc
+	}
+	return rpcUnknown
+}
+
+// HTTPStatus 获取错误对应的 HTTP 状态码，err 为 nil 时返回 200
+func HTTPStatus(err error) int {
+	if err == nil {
+		return http.StatusOK
+	}
+	return CategoryOf(err).HTTPStatus()
+}
+
+// GRPCCode 获取错误对应的 gRPC 状态码，err 为 nil 时返回 OK
+func GRPCCode(err error) uint32 {
+	if err == nil {
+		return rpcOK
+	}
+	return CategoryOf(err).GRPCCode()
+}
+
+type sentinel struct {
+	err      error
+	category Category
+}
+
+var (
+	sentinelMu sync.RWMutex
+	sentinels  = []sentinel{
+		{context.Canceled, Canceled},
+		{context.DeadlineExceeded, DeadlineExceeded},
+	}
+)
+
+// RegisterSentinel 为第三方哨兵错误指定分类，
+// 错误链中没有错误码时，CategoryOf 通过 errors.Is 匹配已注册的哨兵错误
+func RegisterSentinel(err error, category Category) {
+	sentinelMu.Lock()
+	defer sentinelMu.Unlock()
+	sentinels = append(sentinels, sentinel{err: err, category: category})
+}
+
+// UnregisterSentinel 移除通过 RegisterSentinel 注册的哨兵错误
+func UnregisterSentinel(err error) {
+	sentinelMu.Lock()
+	defer sentinelMu.Unlock()
+	kept := sentinels[:0:0]
+	for _, s := range sentinels {
+		if s.err != err {
+			kept = append(kept, s)
+		}
+	}
+	sentinels = kept
+}
+
+func sentinelCategory(err error) (Category, bool) {
+	sentinelMu.RLock()
+	defer sentinelMu.RUnlock()
+	for _, s := range sentinels {
+		if Is(err, s.err) {
+			return s.category, true
+		}
+	}
+	return Unknown, false
+}
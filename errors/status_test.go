@@ -0,0 +1,63 @@
+package errors_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sanbsy/gopkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHTTPStatus(t *testing.T) {
+	ass := assert.New(t)
+
+	ass.Equal(http.StatusOK, errors.HTTPStatus(nil))
+	ass.Equal(http.StatusInternalServerError, errors.HTTPStatus(io.EOF))
+	ass.Equal(http.StatusNotFound, errors.HTTPStatus(errUserNotFound.WithDetail("uid=1")))
+	ass.Equal(http.StatusGatewayTimeout, errors.HTTPStatus(errors.Wrap(context.DeadlineExceeded, "query")))
+	ass.Equal(uint32(5), errors.GRPCCode(errUserNotFound))
+	ass.Equal(uint32(4), errors.GRPCCode(context.DeadlineExceeded))
+
+	errors.RegisterSentinel(io.ErrUnexpectedEOF, errors.Unavailable)
+	defer errors.UnregisterSentinel(io.ErrUnexpectedEOF)
+	ass.Equal(http.StatusServiceUnavailable, errors.HTTPStatus(errors.Wrap(io.ErrUnexpectedEOF, "read")))
+
+	errors.UnregisterSentinel(io.ErrUnexpectedEOF)
+	ass.Equal(http.StatusInternalServerError, errors.HTTPStatus(errors.Wrap(io.ErrUnexpectedEOF, "read")))
+	ass.Equal(http.StatusGatewayTimeout, errors.HTTPStatus(context.DeadlineExceeded))
+}
+
+func TestWriteProblem(t *testing.T) {
+	ass := assert.New(t)
+
+	w := httptest.NewRecorder()
+	err := errors.Wrap(errUserNotFound.WithDetail("select * from users where id = 1"), "load user")
+	ass.Nil(errors.WriteProblem(w, err, "req-1"))
+
+	ass.Equal(http.StatusNotFound, w.Code)
+	ass.Equal(errors.ProblemContentType, w.Header().Get("Content-Type"))
+	ass.NotContains(w.Body.String(), "select")
+
+	var p errors.Problem
+	ass.Nil(json.Unmarshal(w.Body.Bytes(), &p))
+	ass.Equal(errors.Problem{
+		Type:          "about:blank",
+		Title:         "Not Found",
+		Status:        http.StatusNotFound,
+		Detail:        "user not found",
+		Code:          "test.user_not_found",
+		CorrelationID: "req-1",
+	}, p)
+}
+
+func TestNewProblem_NonStandardStatus(t *testing.T) {
+	ass := assert.New(t)
+
+	p := errors.NewProblem(errors.Wrap(context.Canceled, "query"), "")
+	ass.Equal(errors.StatusClientClosedRequest, p.Status)
+	ass.Equal("canceled", p.Title)
+}
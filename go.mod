@@ -8,4 +8,5 @@ require (
 	github.com/stretchr/testify v1.6.1
 	go.etcd.io/bbolt v1.3.5
 	go.uber.org/zap v1.16.0
+	gopkg.in/yaml.v3 v3.0.0-20200313102051-9f266ea9e77c
 )
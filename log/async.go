@@ -0,0 +1,264 @@
+package log
+
+import (
+	"io"
+	"sync"
+	"time"
+
+	"github.com/sanbsy/gopkg/bufferpool"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// 缓冲区已满时的处理策略
+const (
+	// OverflowBlock 阻塞等待缓冲区空闲
+	OverflowBlock = "block"
+	// OverflowDropNewest 丢弃当前写入的日志
+	OverflowDropNewest = "drop_newest"
+	// OverflowDropOldest 丢弃缓冲区中最早的日志
+	OverflowDropOldest = "drop_oldest"
+)
+
+// AsyncOptions 异步写入配置
+type AsyncOptions struct {
+	// 缓冲的最大日志条数，默认 4096
+	BufferSize int `json:"buffer_size" yaml:"buffer_size" mapstructure:"buffer_size"`
+
+	// 缓冲的字节数达到该值时立即刷新，默认 64KB
+	FlushSize int `json:"flush_size" yaml:"flush_size" mapstructure:"flush_size"`
+
+	// 定时刷新间隔，默认 1s
+	FlushInterval Duration `json:"flush_interval" yaml:"flush_interval" mapstructure:"flush_interval"`
+
+	// 缓冲区已满时的处理策略，默认 block
+	Overflow string `json:"overflow" yaml:"overflow" mapstructure:"overflow"`
+}
+
+func (opts *AsyncOptions) loadDefault() {
+	if opts.BufferSize <= 0 {
+		opts.BufferSize = 4096
+	}
+	if opts.FlushSize <= 0 {
+		opts.FlushSize = 64 * 1024
+	}
+	if opts.FlushInterval <= 0 {
+		opts.FlushInterval = Duration(time.Second)
+	}
+	if opts.Overflow == "" {
+		opts.Overflow = OverflowBlock
+	}
+}
+
+// AsyncWriter 异步写入日志，实现 zapcore.WriteSyncer
+// 日志先写入有界的环形缓冲区，由后台协程按大小或时间批量写入下游
+type AsyncWriter struct {
+	w    io.Writer
+	opts AsyncOptions
+	// enc 记录丢弃数量的日志所用的编码器
+	enc zapcore.Encoder
+
+	mu      sync.Mutex
+	notFull *sync.Cond
+	ring    [][]byte
+	head    int
+	count   int
+	size    int
+	dropped int
+	total   int
+	closed  bool
+
+	kick    chan struct{}
+	flushCh chan chan error
+	done    chan struct{}
+	stopped chan struct{}
+}
+
+// NewAsyncWriter 创建 AsyncWriter，并启动后台刷新协程
+func NewAsyncWriter(w io.Writer, opts *AsyncOptions) *AsyncWriter {
+	if opts == nil {
+		opts = &AsyncOptions{}
+	}
+	o := *opts
+	o.loadDefault()
+
+	a := &AsyncWriter{
+		w:       w,
+		opts:    o,
+		enc:     newEncoder(JSONFormat, NewDefaultEncoderConfig()),
+		ring:    make([][]byte, o.BufferSize),
+		kick:    make(chan struct{}, 1),
+		flushCh: make(chan chan error),
+		done:    make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+	a.notFull = sync.NewCond(&a.mu)
+	go a.run()
+	return a
+}
+
+// Write 将日志写入缓冲区，data 会被复制
+// 关闭后直接同步写入下游
+func (a *AsyncWriter) Write(data []byte) (int, error) {
+	a.mu.Lock()
+	if a.closed {
+		a.mu.Unlock()
+		return a.w.Write(data)
+	}
+
+	for a.count == len(a.ring) {
+		switch a.opts.Overflow {
+		case OverflowDropNewest:
+			a.dropped++
+			a.total++
+			a.mu.Unlock()
+			return len(data), nil
+		case OverflowDropOldest:
+			a.size -= len(a.ring[a.head])
+			a.ring[a.head] = nil
+			a.head = (a.head + 1) % len(a.ring)
+			a.count--
+			a.dropped++
+			a.total++
+		default:
+			a.signal()
+			a.notFull.Wait()
+			if a.closed {
+				a.mu.Unlock()
+				return a.w.Write(data)
+			}
+		}
+	}
+
+	entry := make([]byte, len(data))
+	copy(entry, data)
+	a.ring[(a.head+a.count)%len(a.ring)] = entry
+	a.count++
+	a.size += len(entry)
+	if a.size >= a.opts.FlushSize {
+		a.signal()
+	}
+	a.mu.Unlock()
+	return len(data), nil
+}
+
+// Sync 将缓冲区中的日志全部写入下游，并同步下游
+func (a *AsyncWriter) Sync() error {
+	req := make(chan error)
+	select {
+	case a.flushCh <- req:
+		return <-req
+	case <-a.stopped:
+		return a.syncDownstream()
+	}
+}
+
+// Close 停止后台协程，并将缓冲区中的日志全部写入下游
+// 不会关闭下游 writer
+func (a *AsyncWriter) Close() error {
+	a.mu.Lock()
+	if a.closed {
+		a.mu.Unlock()
+		return nil
+	}
+	a.closed = true
+	a.notFull.Broadcast()
+	a.mu.Unlock()
+
+	close(a.done)
+	<-a.stopped
+	return a.syncDownstream()
+}
+
+// Dropped 获取缓冲区满时累计丢弃的日志条数
+func (a *AsyncWriter) Dropped() int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.total
+}
+
+// setEncoder 设置记录丢弃数量的日志所用的编码器，与写入的日志格式保持一致
+func (a *AsyncWriter) setEncoder(enc zapcore.Encoder) {
+	a.mu.Lock()
+	a.enc = enc
+	a.mu.Unlock()
+}
+
+func (a *AsyncWriter) signal() {
+	select {
+	case a.kick <- struct{}{}:
+	default:
+	}
+}
+
+func (a *AsyncWriter) run() {
+	defer close(a.stopped)
+
+	ticker := time.NewTicker(time.Duration(a.opts.FlushInterval))
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			_ = a.flush()
+		case <-a.kick:
+			_ = a.flush()
+		case req := <-a.flushCh:
+			err := a.flush()
+			if e := a.syncDownstream(); err == nil {
+				err = e
+			}
+			req <- err
+		case <-a.done:
+			_ = a.flush()
+			return
+		}
+	}
+}
+
+// flush 取出缓冲区中的所有日志，合并后一次写入下游
+func (a *AsyncWriter) flush() error {
+	buf := bufferpool.Get()
+	defer buf.Free()
+
+	a.mu.Lock()
+	for a.count > 0 {
+		buf.WriteBytes(a.ring[a.head])
+		a.ring[a.head] = nil
+		a.head = (a.head + 1) % len(a.ring)
+		a.count--
+	}
+	a.size = 0
+	dropped, enc := a.dropped, a.enc
+	a.dropped = 0
+	a.notFull.Broadcast()
+	a.mu.Unlock()
+
+	if dropped > 0 {
+		writeDroppedRecord(buf, enc, dropped)
+	}
+	if buf.Len() == 0 {
+		return nil
+	}
+	_, err := a.w.Write(buf.Bytes())
+	return err
+}
+
+func (a *AsyncWriter) syncDownstream() error {
+	if s, ok := a.w.(interface{ Sync() error }); ok {
+		return s.Sync()
+	}
+	return nil
+}
+
+// writeDroppedRecord 写入一条记录丢弃数量的日志
+func writeDroppedRecord(buf *bufferpool.Buffer, enc zapcore.Encoder, dropped int) {
+	entry := zapcore.Entry{Level: zapcore.WarnLevel, Time: time.Now(), Message: "async log buffer overflow"}
+	out, err := enc.EncodeEntry(entry, []zapcore.Field{zap.Int("dropped", dropped)})
+	if err != nil {
+		return
+	}
+	buf.WriteBytes(out.Bytes())
+	out.Free()
+}
@@ -0,0 +1,129 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAsyncWriter_Sync(t *testing.T) {
+	ass := assert.New(t)
+	var out bytes.Buffer
+	w := NewAsyncWriter(&out, &AsyncOptions{FlushInterval: Duration(time.Hour)})
+	defer w.Close()
+
+	_, err := w.Write([]byte("a\n"))
+	ass.Nil(err)
+	_, err = w.Write([]byte("b\n"))
+	ass.Nil(err)
+
+	ass.Nil(w.Sync())
+	ass.Equal("a\nb\n", out.String())
+}
+
+func TestAsyncWriter_Overflow(t *testing.T) {
+	cases := []struct {
+		overflow string
+		expect   string
+	}{
+		{OverflowDropNewest, "a\nb\n"},
+		{OverflowDropOldest, "b\nc\n"},
+	}
+	for _, c := range cases {
+		t.Run(c.overflow, func(t *testing.T) {
+			ass := assert.New(t)
+			var out bytes.Buffer
+			w := NewAsyncWriter(&out, &AsyncOptions{
+				BufferSize:    2,
+				FlushInterval: Duration(time.Hour),
+				Overflow:      c.overflow,
+			})
+			for _, s := range []string{"a\n", "b\n", "c\n"} {
+				n, err := w.Write([]byte(s))
+				ass.Nil(err)
+				ass.Equal(len(s), n)
+			}
+			ass.Nil(w.Close())
+			ass.Equal(1, w.Dropped())
+
+			lines := strings.SplitAfterN(out.String(), "\n", 3)
+			ass.Equal(c.expect, lines[0]+lines[1])
+
+			var record map[string]interface{}
+			ass.Nil(json.Unmarshal([]byte(lines[2]), &record))
+			ass.Equal("warn", record["@level"])
+			ass.Equal(float64(1), record["dropped"])
+		})
+	}
+}
+
+func TestAsyncWriter_ConsoleDroppedRecord(t *testing.T) {
+	ass := assert.New(t)
+	var out bytes.Buffer
+	w := NewAsyncWriter(&out, &AsyncOptions{BufferSize: 1, FlushInterval: Duration(time.Hour), Overflow: OverflowDropNewest})
+	w.setEncoder(newEncoder(TextFormat, NewDefaultEncoderConfig()))
+	for _, s := range []string{"a\n", "b\n"} {
+		_, err := w.Write([]byte(s))
+		ass.Nil(err)
+	}
+	ass.Nil(w.Close())
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	ass.Len(lines, 2)
+	ass.Equal("a", lines[0])
+	ass.Contains(lines[1], "warn\tasync log buffer overflow\t{\"dropped\": 1}")
+}
+
+func TestAsyncWriter_Block(t *testing.T) {
+	ass := assert.New(t)
+	var out bytes.Buffer
+	w := NewAsyncWriter(&out, &AsyncOptions{BufferSize: 1, FlushInterval: Duration(time.Hour)})
+
+	for i := 0; i < 10; i++ {
+		_, err := w.Write([]byte("x\n"))
+		ass.Nil(err)
+	}
+	ass.Nil(w.Close())
+	ass.Equal(0, w.Dropped())
+	ass.Equal(strings.Repeat("x\n", 10), out.String())
+
+	_, err := w.Write([]byte("after\n"))
+	ass.Nil(err)
+	ass.True(strings.HasSuffix(out.String(), "after\n"))
+}
+
+func TestLogger_AsyncOutput(t *testing.T) {
+	ass := assert.New(t)
+	var out bytes.Buffer
+	w := NewAsyncWriter(&out, &AsyncOptions{FlushInterval: Duration(time.Hour)})
+	defer w.Close()
+
+	logger := NewLogger(JSONFormat, FatalLevel).WithOutput(w, InfoLevel, JSONFormat)
+	logger.Info("hello async")
+	ass.Equal(0, out.Len())
+
+	logger.Sync()
+	ass.Contains(out.String(), `"@message":"hello async"`)
+}
+
+func TestNewLoggerWithConfig_AsyncOutput(t *testing.T) {
+	ass := assert.New(t)
+	path := t.TempDir() + "/app.log"
+	config := NewDefaultConfig(JSONFormat, InfoLevel)
+	config.OutputPaths = []string{path}
+
+	logger, err := NewLoggerWithConfig(config, AsyncOutput(&AsyncOptions{FlushInterval: Duration(time.Hour)}))
+	ass.Nil(err)
+	logger.Info("hello file")
+	logger.Close()
+
+	data, err := ioutil.ReadFile(path)
+	ass.Nil(err)
+	ass.Contains(string(data), `"@message":"hello file"`)
+	ass.Contains(string(data), `"@caller":"log/async_test.go`)
+}
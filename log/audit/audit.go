@@ -0,0 +1,400 @@
+// Package audit 防篡改的审计日志
+//
+// 每条记录包含递增的序号以及与上一条记录相连的 SHA-256 hash，
+// 可选地定期以 RSA 或 ECDSA 签名，通过 Verify 检查记录是否被删除、调换或修改
+package audit
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/sanbsy/gopkg/errors"
+	"github.com/sanbsy/gopkg/log"
+	"github.com/sanbsy/gopkg/log/reader"
+	"github.com/sanbsy/gopkg/secret"
+)
+
+// 记录类型
+const (
+	TypeEvent     = "event"
+	TypeSignature = "signature"
+)
+
+// 签名算法
+const (
+	AlgorithmRSA   = "rsa"
+	AlgorithmECDSA = "ecdsa"
+)
+
+// hashKey 写在每行末尾的 hash 字段
+const hashKey = `,"hash":"`
+
+// ErrClosed 审计日志已关闭
+var ErrClosed = errors.New("audit: logger is closed")
+
+type (
+	// Options 审计日志配置
+	Options struct {
+		// 日志文件与切割配置
+		Rotate log.Options `json:"rotate" yaml:"rotate" mapstructure:"rotate"`
+
+		// 签名私钥，RSAKey 与 ECDSAKey 二选一，都为空时不签名
+		RSAKey   *rsa.PrivateKey   `json:"-" yaml:"-" mapstructure:"-"`
+		ECDSAKey *ecdsa.PrivateKey `json:"-" yaml:"-" mapstructure:"-"`
+
+		// 签名的 hash 算法，默认 SHA256
+		SignMethod string `json:"sign_method" yaml:"sign_method" mapstructure:"sign_method"`
+
+		// 每写入多少条记录签名一次，配置私钥时默认 100
+		SignEvery int `json:"sign_every" yaml:"sign_every" mapstructure:"sign_every"`
+
+		// 定时签名间隔，有新记录时签名，0 表示不定时签名
+		SignInterval log.Duration `json:"sign_interval" yaml:"sign_interval" mapstructure:"sign_interval"`
+	}
+
+	// Event 审计事件
+	Event struct {
+		// 操作人
+		Actor string
+		// 操作，如 user.delete
+		Action string
+		// 操作对象
+		Target string
+		// 其他信息
+		Fields log.Fields
+	}
+
+	// Record 审计日志中的一条记录
+	// 签名记录的 Signature 是对上一条记录 Hash 的签名
+	Record struct {
+		Seq       uint64                 `json:"seq"`
+		Time      time.Time              `json:"time"`
+		Type      string                 `json:"type"`
+		Actor     string                 `json:"actor,omitempty"`
+		Action    string                 `json:"action,omitempty"`
+		Target    string                 `json:"target,omitempty"`
+		Fields    map[string]interface{} `json:"fields,omitempty"`
+		Algorithm string                 `json:"alg,omitempty"`
+		Method    string                 `json:"method,omitempty"`
+		Signature string                 `json:"signature,omitempty"`
+		// Prev 上一条记录的 Hash，第一条记录为空
+		Prev string `json:"prev"`
+		// Hash 对 Prev 与记录内容计算的 SHA-256，写在每行末尾
+		Hash string `json:"-"`
+	}
+
+	// Logger 审计日志，只追加写入，并发安全
+	Logger struct {
+		opts Options
+		w    *log.RotateWriter
+
+		mu       sync.Mutex
+		seq      uint64
+		prev     string
+		unsigned int
+		closed   bool
+
+		done    chan struct{}
+		stopped chan struct{}
+	}
+)
+
+// NewLogger 创建审计日志，已有日志文件时从最后一条记录继续，opts 为 nil 时使用默认配置
+// 配置私钥且最后一条记录不是签名时（如进程异常退出），打开后立即签名
+func NewLogger(opts *Options) (*Logger, error) {
+	if opts == nil {
+		opts = &Options{}
+	}
+	// 自动删除备份文件会使哈希链缺失开头，应由外部归档，校验时使用 AllowMissingHead
+	if opts.Rotate.MaxBackups > 0 || opts.Rotate.MaxAge > 0 {
+		return nil, errors.New("audit: MaxBackups and MaxAge are not supported, archive old files externally")
+	}
+	o := *opts
+	if o.SignMethod == "" {
+		o.SignMethod = secret.SigningMethodRSA256
+	}
+	signing := o.RSAKey != nil || o.ECDSAKey != nil
+	if signing && o.SignEvery <= 0 {
+		o.SignEvery = 100
+	}
+
+	// NewWriter 会加载默认的文件名与备份目录
+	l := &Logger{opts: o, w: log.NewWriter(&o.Rotate)}
+	last, err := lastRecord(&o.Rotate)
+	if err != nil {
+		_ = l.w.Close()
+		return nil, err
+	}
+	if last != nil {
+		l.seq, l.prev = last.Seq, last.Hash
+		if signing && last.Type != TypeSignature {
+			l.unsigned = 1
+			if err := l.sign(); err != nil {
+				_ = l.w.Close()
+				return nil, err
+			}
+		}
+	}
+
+	if signing && o.SignInterval > 0 {
+		l.done, l.stopped = make(chan struct{}), make(chan struct{})
+		go l.run()
+	}
+	return l, nil
+}
+
+// Log 写入一条审计事件
+func (l *Logger) Log(e Event) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.closed {
+		return ErrClosed
+	}
+
+	err := l.append(&Record{
+		Type:   TypeEvent,
+		Actor:  e.Actor,
+		Action: e.Action,
+		Target: e.Target,
+		Fields: e.Fields,
+	})
+	if err != nil {
+		return err
+	}
+	if l.opts.SignEvery > 0 && l.unsigned >= l.opts.SignEvery {
+		return l.sign()
+	}
+	return nil
+}
+
+// Sign 对当前最后一条记录签名，未配置私钥或没有新记录时不写入
+func (l *Logger) Sign() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.closed {
+		return ErrClosed
+	}
+	return l.sign()
+}
+
+// Close 签名后关闭日志文件
+func (l *Logger) Close() error {
+	l.mu.Lock()
+	if l.closed {
+		l.mu.Unlock()
+		return nil
+	}
+	err := l.sign()
+	l.closed = true
+	l.mu.Unlock()
+
+	if l.done != nil {
+		close(l.done)
+		<-l.stopped
+	}
+	if e := l.w.Close(); e != nil && err == nil {
+		err = e
+	}
+	return err
+}
+
+// Rotate 切割日志文件，哈希链在新文件中延续
+func (l *Logger) Rotate() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.closed {
+		return ErrClosed
+	}
+	return l.w.Rotate()
+}
+
+func (l *Logger) run() {
+	defer close(l.stopped)
+	ticker := time.NewTicker(time.Duration(l.opts.SignInterval))
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			_ = l.Sign()
+		case <-l.done:
+			return
+		}
+	}
+}
+
+// sign 调用方需持有锁
+func (l *Logger) sign() error {
+	if l.unsigned == 0 || (l.opts.RSAKey == nil && l.opts.ECDSAKey == nil) {
+		return nil
+	}
+	r := &Record{Type: TypeSignature, Method: l.opts.SignMethod}
+	var err error
+	if l.opts.RSAKey != nil {
+		r.Algorithm = AlgorithmRSA
+		r.Signature, err = secret.RSASign([]byte(l.prev), r.Method, l.opts.RSAKey)
+	} else {
+		r.Algorithm = AlgorithmECDSA
+		r.Signature, err = secret.ECDSASign([]byte(l.prev), r.Method, l.opts.ECDSAKey)
+	}
+	if err != nil {
+		return err
+	}
+	return l.append(r)
+}
+
+// append 设置序号与 hash 后写入，调用方需持有锁
+func (l *Logger) append(r *Record) error {
+	r.Seq = l.seq + 1
+	r.Time = time.Now()
+	r.Prev = l.prev
+
+	line, err := encode(r)
+	if err != nil {
+		return err
+	}
+	if _, err := l.w.Write(line); err != nil {
+		return err
+	}
+	l.seq, l.prev = r.Seq, r.Hash
+	if r.Type == TypeSignature {
+		l.unsigned = 0
+	} else {
+		l.unsigned++
+	}
+	return nil
+}
+
+// encode 编码记录并计算 hash，hash 以最后一个字段的形式追加到行尾
+func encode(r *Record) ([]byte, error) {
+	body, err := json.Marshal(r)
+	if err != nil {
+		return nil, err
+	}
+	r.Hash = hash(r.Prev, body)
+
+	line := make([]byte, 0, len(body)+len(hashKey)+len(r.Hash)+3)
+	line = append(line, body[:len(body)-1]...)
+	line = append(line, hashKey...)
+	line = append(line, r.Hash...)
+	line = append(line, '"', '}', '\n')
+	return line, nil
+}
+
+// decode 解析一行记录，返回记录与不含 hash 的内容
+func decode(line []byte) (*Record, []byte, error) {
+	line = bytes.TrimRight(line, "\r\n")
+	n := len(line) - 2 - sha256.Size*2 - len(hashKey)
+	if n <= 0 || !bytes.HasSuffix(line, []byte(`"}`)) || !bytes.Equal(line[n:n+len(hashKey)], []byte(hashKey)) {
+		return nil, nil, errors.New("audit: malformed record")
+	}
+	body := make([]byte, 0, n+1)
+	body = append(append(body, line[:n]...), '}')
+
+	r := &Record{}
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	if err := dec.Decode(r); err != nil {
+		return nil, nil, errors.New("audit: malformed record")
+	}
+	r.Hash = string(line[n+len(hashKey) : len(line)-2])
+	return r, body, nil
+}
+
+func hash(prev string, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(prev))
+	h.Write([]byte{'\n'})
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// files 列出日志文件及其备份文件，按第一条记录的序号排列
+// 备份文件被修改后修改时间会变化，因此不依赖修改时间排序
+func files(filename, backupPath, suffixFormat string) ([]string, error) {
+	paths, err := reader.Files(filename, backupPath, suffixFormat)
+	if err != nil {
+		return nil, err
+	}
+	seqs := make(map[string]uint64, len(paths))
+	for _, path := range paths {
+		if seqs[path], err = firstSeq(path); err != nil {
+			return nil, err
+		}
+	}
+	sort.SliceStable(paths, func(i, j int) bool {
+		return seqs[paths[i]] < seqs[paths[j]]
+	})
+	return paths, nil
+}
+
+// firstSeq 文件中第一条记录的序号，无法解析时返回 0
+func firstSeq(path string) (uint64, error) {
+	var seq uint64
+	err := scan(path, func(r *Record, _ []byte, err error) bool {
+		if err == nil {
+			seq = r.Seq
+		}
+		return false
+	})
+	return seq, err
+}
+
+// lastRecord 最后一条可以解析的记录
+func lastRecord(opts *log.Options) (*Record, error) {
+	paths, err := files(opts.FileName, opts.BackupPath, opts.SuffixFormat)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var last *Record
+	for i := len(paths) - 1; i >= 0 && last == nil; i-- {
+		err := scan(paths[i], func(r *Record, _ []byte, err error) bool {
+			if err == nil && (last == nil || r.Seq > last.Seq) {
+				last = r
+			}
+			return true
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return last, nil
+}
+
+// scan 逐行解析文件中的记录，fn 返回 false 时停止
+func scan(path string, fn func(r *Record, body []byte, err error) bool) error {
+	f, err := reader.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	br := bufio.NewReader(f)
+	for {
+		line, err := br.ReadBytes('\n')
+		if len(bytes.TrimSpace(line)) > 0 {
+			r, body, derr := decode(line)
+			if !fn(r, body, derr) {
+				return nil
+			}
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,221 @@
+package audit
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sanbsy/gopkg/log"
+	"github.com/sanbsy/gopkg/secret"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeEvents(t *testing.T, opts *Options, actions ...string) {
+	l, err := NewLogger(opts)
+	require.Nil(t, err)
+	for _, action := range actions {
+		require.Nil(t, l.Log(Event{Actor: "admin", Action: action, Target: "u1", Fields: log.Fields{"ip": "127.0.0.1"}}))
+	}
+	require.Nil(t, l.Close())
+}
+
+func readLines(t *testing.T, path string) []string {
+	data, err := ioutil.ReadFile(path)
+	require.Nil(t, err)
+	return strings.SplitAfter(strings.TrimSuffix(string(data), "\n"), "\n")
+}
+
+func writeLines(t *testing.T, path string, lines []string) {
+	data := strings.Join(lines, "")
+	if !strings.HasSuffix(data, "\n") {
+		data += "\n"
+	}
+	require.Nil(t, ioutil.WriteFile(path, []byte(data), 0644))
+}
+
+func TestLogger_RSA(t *testing.T) {
+	ass := assert.New(t)
+	key, err := secret.CreateRSAKey(1024)
+	require.Nil(t, err)
+	filename := filepath.Join(t.TempDir(), "audit.log")
+	opts := &Options{Rotate: log.Options{FileName: filename}, RSAKey: key.PrivateKey(), SignEvery: 2}
+
+	writeEvents(t, opts, "user.create", "user.update", "user.delete")
+	// 重新打开后哈希链延续
+	writeEvents(t, opts, "role.grant")
+
+	report, err := Verify(filename, "", &VerifyOptions{RSAKey: key.PublicKey()})
+	require.Nil(t, err)
+	ass.True(report.OK(), "%v", report.Problems)
+	// 4 条事件，第 2 条后、两次关闭时各一条签名
+	ass.Equal(7, report.Records)
+	ass.Equal(uint64(1), report.FirstSeq)
+	ass.Equal(uint64(7), report.LastSeq)
+	ass.Equal(3, report.Signatures)
+	ass.Equal(0, report.Unsigned)
+
+	r, _, err := decode([]byte(readLines(t, filename)[0]))
+	require.Nil(t, err)
+	ass.Equal(TypeEvent, r.Type)
+	ass.Equal("user.create", r.Action)
+	ass.Equal("127.0.0.1", r.Fields["ip"])
+	ass.Equal("", r.Prev)
+
+	other, err := secret.CreateRSAKey(1024)
+	require.Nil(t, err)
+	report, err = Verify(filename, "", &VerifyOptions{RSAKey: other.PublicKey()})
+	require.Nil(t, err)
+	ass.Len(report.Problems, 3)
+	ass.Equal(ProblemSignature, report.Problems[0].Kind)
+	ass.Equal(4, report.Unsigned)
+}
+
+func TestLogger_SignOnOpen(t *testing.T) {
+	ass := assert.New(t)
+	key, err := secret.CreateECDSAKey()
+	require.Nil(t, err)
+	filename := filepath.Join(t.TempDir(), "audit.log")
+	opts := &Options{Rotate: log.Options{FileName: filename}, ECDSAKey: key.PrivateKey()}
+
+	// 未关闭即退出，最后一条记录没有签名
+	l, err := NewLogger(opts)
+	require.Nil(t, err)
+	require.Nil(t, l.Log(Event{Action: "a"}))
+	require.Nil(t, l.w.Close())
+
+	l, err = NewLogger(opts)
+	require.Nil(t, err)
+	report, err := Verify(filename, "", &VerifyOptions{ECDSAKey: key.PublicKey()})
+	require.Nil(t, err)
+	ass.True(report.OK(), "%v", report.Problems)
+	ass.Equal(0, report.Unsigned)
+	ass.Equal(1, report.Signatures)
+	require.Nil(t, l.Close())
+}
+
+func TestLogger_RestartSamePeriod(t *testing.T) {
+	ass := assert.New(t)
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "audit.log")
+	opts := &Options{Rotate: log.Options{FileName: filename, RotateInterval: log.RotateDaily}}
+
+	// 同一周期内重启后再次切割，不能覆盖上次生成的备份
+	for _, actions := range [][]string{{"a", "b"}, {"c", "d"}} {
+		l, err := NewLogger(opts)
+		require.Nil(t, err)
+		require.Nil(t, l.Log(Event{Action: actions[0]}))
+		require.Nil(t, l.Rotate())
+		require.Nil(t, l.Log(Event{Action: actions[1]}))
+		require.Nil(t, l.Close())
+	}
+
+	report, err := Verify(filename, "", nil)
+	require.Nil(t, err)
+	ass.True(report.OK(), "%v", report.Problems)
+	ass.Equal(4, report.Records)
+	ass.Equal(uint64(1), report.FirstSeq)
+	ass.Equal(uint64(4), report.LastSeq)
+}
+
+func TestNewLogger_Options(t *testing.T) {
+	ass := assert.New(t)
+	filename := filepath.Join(t.TempDir(), "audit.log")
+	_, err := NewLogger(&Options{Rotate: log.Options{FileName: filename, MaxBackups: 3}})
+	ass.NotNil(err)
+	_, err = NewLogger(&Options{Rotate: log.Options{FileName: filename, MaxAge: 7}})
+	ass.NotNil(err)
+
+	l, err := NewLogger(nil)
+	require.Nil(t, err)
+	ass.Nil(l.Close())
+
+	var o Options
+	ass.Nil(json.Unmarshal([]byte(`{"sign_interval":"1m"}`), &o))
+	ass.Equal(log.Duration(time.Minute), o.SignInterval)
+}
+
+func TestLogger_ECDSA(t *testing.T) {
+	key, err := secret.CreateECDSAKey()
+	require.Nil(t, err)
+	filename := filepath.Join(t.TempDir(), "audit.log")
+	writeEvents(t, &Options{Rotate: log.Options{FileName: filename}, ECDSAKey: key.PrivateKey()}, "a", "b")
+
+	report, err := Verify(filename, "", &VerifyOptions{ECDSAKey: key.PublicKey()})
+	require.Nil(t, err)
+	assert.True(t, report.OK(), "%v", report.Problems)
+	assert.Equal(t, 1, report.Signatures)
+}
+
+func TestVerify_Tampered(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "audit.log")
+	l, err := NewLogger(&Options{Rotate: log.Options{FileName: filename}})
+	require.Nil(t, err)
+	for _, action := range []string{"a", "b", "c"} {
+		require.Nil(t, l.Log(Event{Action: action}))
+	}
+	require.Nil(t, l.Rotate())
+	for _, action := range []string{"d", "e", "f"} {
+		require.Nil(t, l.Log(Event{Action: action}))
+	}
+	require.Nil(t, l.Close())
+
+	report, err := Verify(filename, "", nil)
+	require.Nil(t, err)
+	require.True(t, report.OK(), "%v", report.Problems)
+	require.Len(t, report.Files, 2)
+	assert.Equal(t, 6, report.Records)
+	backup, lines := report.Files[0], readLines(t, filename)
+
+	cases := []struct {
+		name   string
+		path   string
+		modify func([]string) []string
+		kinds  []string
+	}{
+		{"modified", filename, func(lines []string) []string {
+			lines[1] = strings.Replace(lines[1], `"action":"e"`, `"action":"x"`, 1)
+			return lines
+		}, []string{ProblemModified}},
+		{"deleted", filename, func(lines []string) []string {
+			return append(lines[:1:1], lines[2:]...)
+		}, []string{ProblemMissing}},
+		{"reordered", filename, func(lines []string) []string {
+			lines[0], lines[1] = lines[1], lines[0]
+			return lines
+		}, []string{ProblemMissing, ProblemReordered}},
+		{"malformed", filename, func(lines []string) []string {
+			lines[2] = "{}\n"
+			return lines
+		}, []string{ProblemMalformed}},
+		{"head deleted", backup, func(lines []string) []string {
+			return lines[1:]
+		}, []string{ProblemMissing}},
+	}
+	for _, c := range cases {
+		origin := readLines(t, c.path)
+		writeLines(t, c.path, c.modify(append([]string(nil), origin...)))
+
+		report, err := Verify(filename, "", nil)
+		require.Nil(t, err, c.name)
+		var kinds []string
+		for _, p := range report.Problems {
+			kinds = append(kinds, p.Kind)
+		}
+		assert.Equal(t, c.kinds, kinds, c.name)
+
+		writeLines(t, c.path, origin)
+	}
+	assert.Equal(t, lines, readLines(t, filename))
+
+	// 允许缺失开头的记录
+	writeLines(t, backup, readLines(t, backup)[1:])
+	report, err = Verify(filename, "", &VerifyOptions{AllowMissingHead: true})
+	require.Nil(t, err)
+	assert.True(t, report.OK(), "%v", report.Problems)
+	assert.Equal(t, uint64(2), report.FirstSeq)
+}
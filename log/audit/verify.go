@@ -0,0 +1,181 @@
+package audit
+
+import (
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"fmt"
+
+	"github.com/sanbsy/gopkg/errors"
+	"github.com/sanbsy/gopkg/secret"
+)
+
+// 校验发现的问题类型
+const (
+	// ProblemMalformed 记录无法解析
+	ProblemMalformed = "malformed"
+	// ProblemModified 记录内容与 hash 不一致
+	ProblemModified = "modified"
+	// ProblemMissing 序号不连续，记录被删除
+	ProblemMissing = "missing"
+	// ProblemReordered 序号倒退或重复，记录被调换或复制
+	ProblemReordered = "reordered"
+	// ProblemBrokenChain 记录的 prev 与上一条记录的 hash 不一致
+	ProblemBrokenChain = "broken_chain"
+	// ProblemSignature 签名校验失败
+	ProblemSignature = "bad_signature"
+)
+
+type (
+	// VerifyOptions 校验配置
+	VerifyOptions struct {
+		// 签名公钥，与写入时的私钥对应，都为空时不校验签名
+		RSAKey   *rsa.PublicKey
+		ECDSAKey *ecdsa.PublicKey
+
+		// 备份文件的时间后缀格式，为空时使用 RotateWriter 的默认格式
+		SuffixFormat string
+
+		// 允许第一条记录的序号大于 1，用于旧的备份文件已被外部归档或删除的情况
+		AllowMissingHead bool
+	}
+
+	// Problem 校验发现的问题
+	Problem struct {
+		File    string
+		Line    int
+		Seq     uint64
+		Kind    string
+		Message string
+	}
+
+	// Report 校验结果
+	Report struct {
+		// 按序号排列的日志文件
+		Files []string
+		// 记录条数，包括签名记录
+		Records  int
+		FirstSeq uint64
+		LastSeq  uint64
+		// 校验通过的签名数量
+		Signatures int
+		// 最后一个校验通过的签名之后的事件数量，这些记录被删除时无法发现
+		Unsigned int
+		Problems []Problem
+	}
+)
+
+func (p Problem) String() string {
+	return fmt.Sprintf("%s:%d: seq %d: %s: %s", p.File, p.Line, p.Seq, p.Kind, p.Message)
+}
+
+// OK 是否没有发现问题
+func (r *Report) OK() bool {
+	return len(r.Problems) == 0
+}
+
+// Verify 校验审计日志及其备份文件，检查被删除、调换或修改的记录
+// 删除末尾的记录只能通过签名发现，参考 Report.Unsigned
+func Verify(filename, backupPath string, opts *VerifyOptions) (*Report, error) {
+	if opts == nil {
+		opts = &VerifyOptions{}
+	}
+	paths, err := files(filename, backupPath, opts.SuffixFormat)
+	if err != nil {
+		return nil, err
+	}
+
+	v := &verifier{opts: opts, report: &Report{Files: paths}}
+	for _, path := range paths {
+		v.file, v.line = path, 0
+		err := scan(path, func(r *Record, body []byte, err error) bool {
+			v.line++
+			if err != nil {
+				v.problem(0, ProblemMalformed, err.Error())
+			} else {
+				v.verify(r, body)
+			}
+			return true
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return v.report, nil
+}
+
+type verifier struct {
+	opts   *VerifyOptions
+	report *Report
+
+	file string
+	line int
+
+	// last 上一条记录，max 已出现的最大序号
+	last *Record
+	max  uint64
+}
+
+func (v *verifier) problem(seq uint64, kind, format string, args ...interface{}) {
+	v.report.Problems = append(v.report.Problems, Problem{
+		File:    v.file,
+		Line:    v.line,
+		Seq:     seq,
+		Kind:    kind,
+		Message: fmt.Sprintf(format, args...),
+	})
+}
+
+func (v *verifier) verify(r *Record, body []byte) {
+	report := v.report
+	report.Records++
+	if report.FirstSeq == 0 {
+		report.FirstSeq = r.Seq
+	}
+
+	if hash(r.Prev, body) != r.Hash {
+		v.problem(r.Seq, ProblemModified, "hash mismatch")
+	}
+
+	switch {
+	case v.last == nil:
+		if r.Seq != 1 && !v.opts.AllowMissingHead {
+			v.problem(r.Seq, ProblemMissing, "records 1-%d are missing", r.Seq-1)
+		} else if r.Seq == 1 && r.Prev != "" {
+			v.problem(r.Seq, ProblemBrokenChain, "first record has prev hash")
+		}
+	case r.Seq <= v.max:
+		v.problem(r.Seq, ProblemReordered, "seq after %d", v.last.Seq)
+	case r.Seq > v.max+1:
+		v.problem(r.Seq, ProblemMissing, "records %d-%d are missing", v.max+1, r.Seq-1)
+	case v.last.Seq == r.Seq-1 && r.Prev != v.last.Hash:
+		v.problem(r.Seq, ProblemBrokenChain, "prev hash does not match seq %d", v.last.Seq)
+	}
+	if r.Seq > v.max {
+		v.max = r.Seq
+		report.LastSeq = r.Seq
+	}
+	v.last = r
+
+	if r.Type != TypeSignature {
+		report.Unsigned++
+		return
+	}
+	if v.opts.RSAKey == nil && v.opts.ECDSAKey == nil {
+		return
+	}
+	var err error
+	switch {
+	case r.Algorithm == AlgorithmRSA && v.opts.RSAKey != nil:
+		err = secret.RSAVerify([]byte(r.Prev), r.Signature, r.Method, v.opts.RSAKey)
+	case r.Algorithm == AlgorithmECDSA && v.opts.ECDSAKey != nil:
+		err = secret.ECDSAVerify([]byte(r.Prev), r.Signature, r.Method, v.opts.ECDSAKey)
+	default:
+		err = errors.Errorf("no %s public key", r.Algorithm)
+	}
+	if err != nil {
+		v.problem(r.Seq, ProblemSignature, "%v", err)
+		return
+	}
+	report.Signatures++
+	report.Unsigned = 0
+}
@@ -0,0 +1,249 @@
+package log
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sanbsy/gopkg/errors"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"gopkg.in/yaml.v3"
+)
+
+type (
+	// LoggerConfig 声明式日志配置，可从 YAML/JSON 文件加载
+	LoggerConfig struct {
+		// 全局日志等级，默认 info，可通过 Logger.SetLevel 动态修改
+		Level string `json:"level" yaml:"level" mapstructure:"level"`
+
+		// Logger Name
+		Name string `json:"name" yaml:"name" mapstructure:"name"`
+
+		// 是否关闭调用位置输出
+		DisableCaller bool `json:"disable_caller" yaml:"disable_caller" mapstructure:"disable_caller"`
+
+		// 所有日志附加的字段
+		Fields map[string]interface{} `json:"fields" yaml:"fields" mapstructure:"fields"`
+
+		// 脱敏配置，为空时不脱敏
+		Redact *RedactOptions `json:"redact" yaml:"redact" mapstructure:"redact"`
+
+		// 重复日志合并配置，为空时不合并
+		Dedup *DedupOptions `json:"dedup" yaml:"dedup" mapstructure:"dedup"`
+
+		// 限流配置，为空时不限流
+		RateLimit *RateLimitOptions `json:"rate_limit" yaml:"rate_limit" mapstructure:"rate_limit"`
+
+		// 日志输出列表，为空时输出到 stdout
+		Outputs []OutputConfig `json:"outputs" yaml:"outputs" mapstructure:"outputs"`
+	}
+
+	// OutputConfig 日志输出配置
+	OutputConfig struct {
+		// 输出名称，仅用于标识
+		Name string `json:"name" yaml:"name" mapstructure:"name"`
+
+		// 输出等级，为空时与全局等级一致，低于全局等级的日志不会输出
+		Level string `json:"level" yaml:"level" mapstructure:"level"`
+
+		// 输出格式，json 或 console，默认 json
+		Format string `json:"format" yaml:"format" mapstructure:"format"`
+
+		// 输出路径，stdout、stderr 或文件路径，默认 stdout；
+		// 配置 Rotate 时作为日志文件名，为空使用 Rotate.FileName
+		Path string `json:"path" yaml:"path" mapstructure:"path"`
+
+		// 日志切割配置
+		Rotate *Options `json:"rotate" yaml:"rotate" mapstructure:"rotate"`
+
+		// 采样配置，为空时不采样
+		Sampling *SamplingOption `json:"sampling" yaml:"sampling" mapstructure:"sampling"`
+
+		// 异步写入配置，为空时同步写入
+		Async *AsyncOptions `json:"async" yaml:"async" mapstructure:"async"`
+	}
+)
+
+// LoadConfig 从文件加载日志配置，根据扩展名解析 .json、.yaml、.yml 格式
+func LoadConfig(filename string) (*LoggerConfig, error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	config := &LoggerConfig{}
+	switch ext := strings.ToLower(filepath.Ext(filename)); ext {
+	case ".json":
+		err = json.Unmarshal(data, config)
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, config)
+	default:
+		return nil, errors.Errorf("log: unsupported config file extension %q", ext)
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "log: parse config %s", filename)
+	}
+	return config, nil
+}
+
+// Build 根据配置创建 Logger，返回的 close 函数与 Logger.Close 一致，刷新缓冲并关闭所有输出
+func (c *LoggerConfig) Build() (*Logger, func(), error) {
+	level, err := parseLevel(c.Level, InfoLevel)
+	if err != nil {
+		return nil, nil, err
+	}
+	atomic := zap.NewAtomicLevelAt(level)
+	levels := newLevelRegistry(atomic)
+
+	var redactor *Redactor
+	if c.Redact != nil {
+		if redactor, err = NewRedactor(c.Redact); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	outputs := c.Outputs
+	if len(outputs) == 0 {
+		outputs = []OutputConfig{{Name: "stdout"}}
+	}
+
+	var (
+		cores   = make([]zapcore.Core, 0, len(outputs))
+		closers []func()
+	)
+	closeAll := func() {
+		for i := len(closers) - 1; i >= 0; i-- {
+			closers[i]()
+		}
+	}
+	for i := range outputs {
+		core, closeOut, err := outputs[i].build(levels)
+		if err != nil {
+			closeAll()
+			return nil, nil, errors.Wrapf(err, "log: build output %q", outputs[i].Name)
+		}
+		cores = append(cores, core)
+		closers = append(closers, closeOut)
+	}
+
+	errSink, closeErr, err := zap.Open("stderr")
+	if err != nil {
+		closeAll()
+		return nil, nil, err
+	}
+	closers = append(closers, closeErr)
+	opts := []zap.Option{zap.ErrorOutput(errSink)}
+	if len(c.Fields) > 0 {
+		keys := make([]string, 0, len(c.Fields))
+		for key := range c.Fields {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		fields := make([]zap.Field, 0, len(keys))
+		for _, key := range keys {
+			fields = append(fields, zap.Any(key, c.Fields[key]))
+		}
+		opts = append(opts, zap.Fields(fields...))
+	}
+
+	var core zapcore.Core = &levelCore{Core: zapcore.NewTee(cores...), levels: levels}
+	if c.Dedup != nil {
+		core = newDedupCore(core, c.Dedup)
+	}
+	if c.RateLimit != nil {
+		core = newRateLimitCore(core, c.RateLimit)
+	}
+	if redactor != nil {
+		core = &redactCore{Core: core, r: redactor}
+	}
+	var once sync.Once
+	logger := &Logger{
+		level:     atomic,
+		levels:    levels,
+		logger:    zap.New(core, opts...),
+		addCaller: !c.DisableCaller,
+		close:     func() { once.Do(closeAll) },
+	}
+	if c.Name != "" {
+		logger = logger.SetName(c.Name)
+	}
+	return logger, logger.Close, nil
+}
+
+// build 创建单个输出的 core，日志需同时满足全局等级与输出等级
+func (o *OutputConfig) build(global zapcore.LevelEnabler) (zapcore.Core, func(), error) {
+	level, err := parseLevel(o.Level, DebugLevel)
+	if err != nil {
+		return nil, nil, err
+	}
+	format := o.Format
+	if format == "" {
+		format = JSONFormat
+	}
+	if format != JSONFormat && format != TextFormat {
+		return nil, nil, errors.Errorf("log: unknown output format %q", format)
+	}
+
+	var (
+		ws       zapcore.WriteSyncer
+		closeOut func()
+	)
+	if o.Rotate != nil {
+		opts := *o.Rotate
+		if o.Path != "" {
+			opts.FileName = o.Path
+		}
+		w := NewWriter(&opts)
+		ws, closeOut = zapcore.AddSync(w), func() { _ = w.Close() }
+	} else {
+		path := o.Path
+		if path == "" {
+			path = "stdout"
+		}
+		if ws, closeOut, err = zap.Open(path); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	enc := newEncoder(format, NewDefaultEncoderConfig())
+	if o.Async != nil {
+		a := NewAsyncWriter(ws, o.Async)
+		a.setEncoder(enc.Clone())
+		closeDownstream := closeOut
+		ws, closeOut = a, func() {
+			_ = a.Close()
+			closeDownstream()
+		}
+	}
+
+	enabler := zap.LevelEnablerFunc(func(l zapcore.Level) bool {
+		return global.Enabled(l) && level.Enabled(l)
+	})
+	core := zapcore.NewCore(enc, ws, enabler)
+	if s := o.Sampling; s != nil {
+		tick := s.Tick
+		if tick <= 0 {
+			tick = time.Second
+		}
+		core = zapcore.NewSamplerWithOptions(core, tick, s.Initial, s.Thereafter)
+	}
+	return core, closeOut, nil
+}
+
+// parseLevel 解析日志等级，text 为空时返回 def
+func parseLevel(text string, def Level) (Level, error) {
+	if text == "" {
+		return def, nil
+	}
+	var level Level
+	if err := level.UnmarshalText([]byte(text)); err != nil {
+		return level, errors.Wrapf(err, "log: invalid level %q", text)
+	}
+	return level, nil
+}
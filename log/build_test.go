@@ -0,0 +1,107 @@
+package log
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadConfig_Build(t *testing.T) {
+	ass := assert.New(t)
+	dir := t.TempDir()
+	appLog := filepath.Join(dir, "app.log")
+	errLog := filepath.Join(dir, "error.log")
+
+	configFile := filepath.Join(dir, "log.yaml")
+	require.Nil(t, ioutil.WriteFile(configFile, []byte(`
+level: debug
+name: app
+fields:
+  service: demo
+outputs:
+  - name: app
+    format: json
+    path: `+appLog+`
+    async:
+      flush_interval: 1h
+  - name: error
+    level: error
+    format: console
+    rotate:
+      file_name: `+errLog+`
+      max_size: 1
+      max_backups: 3
+`), 0644))
+
+	config, err := LoadConfig(configFile)
+	require.Nil(t, err)
+	ass.Equal("debug", config.Level)
+	ass.Equal(Duration(time.Hour), config.Outputs[0].Async.FlushInterval)
+	ass.Len(config.Outputs, 2)
+	ass.Equal(3, config.Outputs[1].Rotate.MaxBackups)
+
+	logger, closeFn, err := config.Build()
+	require.Nil(t, err)
+	logger.Debug("debug message")
+	logger.Error("error message")
+	logger.SetLevel(InfoLevel)
+	logger.Debug("hidden message")
+	closeFn()
+	logger.Close()
+
+	data, err := ioutil.ReadFile(appLog)
+	ass.Nil(err)
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	ass.Len(lines, 2)
+	ass.Contains(lines[0], `"@logger":"app"`)
+	ass.Contains(lines[0], `"@message":"debug message"`)
+	ass.Contains(lines[0], `"service":"demo"`)
+	ass.Contains(lines[0], `"@caller":"log/build_test.go`)
+
+	data, err = ioutil.ReadFile(errLog)
+	ass.Nil(err)
+	ass.Contains(string(data), "error message")
+	ass.NotContains(string(data), "debug message")
+}
+
+func TestLoadConfig_JSON(t *testing.T) {
+	ass := assert.New(t)
+	dir := t.TempDir()
+	configFile := filepath.Join(dir, "log.json")
+	require.Nil(t, ioutil.WriteFile(configFile, []byte(`{
+		"level": "warn",
+		"dedup": {"window": "2s"},
+		"rate_limit": {"rate": 10, "interval": "1m"},
+		"outputs": [{
+			"name": "out",
+			"path": "`+filepath.Join(dir, "out.log")+`",
+			"sampling": {"tick": "500ms", "initial": 1, "thereafter": 10},
+			"async": {"flush_interval": 1000000000}
+		}]
+	}`), 0644))
+
+	config, err := LoadConfig(configFile)
+	require.Nil(t, err)
+	ass.Equal(10, config.Outputs[0].Sampling.Thereafter)
+	ass.Equal(500*time.Millisecond, config.Outputs[0].Sampling.Tick)
+	ass.Equal(Duration(time.Second), config.Outputs[0].Async.FlushInterval)
+	ass.Equal(Duration(2*time.Second), config.Dedup.Window)
+	ass.Equal(Duration(time.Minute), config.RateLimit.Interval)
+
+	require.Nil(t, ioutil.WriteFile(configFile, []byte(`{"dedup": {"window": "2 seconds"}}`), 0644))
+	_, err = LoadConfig(configFile)
+	ass.NotNil(err)
+
+	_, _, err = (&LoggerConfig{Level: "verbose"}).Build()
+	ass.NotNil(err)
+	_, _, err = (&LoggerConfig{Outputs: []OutputConfig{{Format: "xml"}}}).Build()
+	ass.NotNil(err)
+
+	_, err = LoadConfig(filepath.Join(dir, "log.toml"))
+	ass.NotNil(err)
+}
@@ -0,0 +1,80 @@
+package log
+
+import (
+	"reflect"
+	"runtime"
+	"strings"
+	"sync"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// logPackage 本包的导入路径
+var logPackage = reflect.TypeOf(Logger{}).PkgPath()
+
+// helpers 通过 Helper 标记的函数名
+var helpers sync.Map
+
+// Helper 将调用 Helper 的函数标记为日志辅助函数，与 testing.T.Helper 类似，
+// 输出日志位置时跳过该函数，在封装日志接口的函数中调用
+func Helper() {
+	var pcs [1]uintptr
+	if runtime.Callers(2, pcs[:]) == 0 {
+		return
+	}
+	frame, _ := runtime.CallersFrames(pcs[:]).Next()
+	if _, ok := helpers.Load(frame.Function); !ok {
+		helpers.Store(frame.Function, struct{}{})
+	}
+}
+
+// caller 返回日志的调用位置：跳过本包的日志接口、标准库 log 与 log/slog，
+// 以及通过 Helper 标记的函数后，再跳过 AddCallerSkip 指定的层数
+func (l *Logger) caller() zapcore.EntryCaller {
+	var pcs [64]uintptr
+	n := runtime.Callers(2, pcs[:])
+	frames := runtime.CallersFrames(pcs[:n])
+	skip := l.callerSkip
+	for {
+		frame, more := frames.Next()
+		if !isWrapperFrame(frame) {
+			if skip <= 0 {
+				return zapcore.NewEntryCaller(frame.PC, frame.File, frame.Line, true)
+			}
+			skip--
+		}
+		if !more {
+			return zapcore.EntryCaller{}
+		}
+	}
+}
+
+// isWrapperFrame 判断调用栈是否属于日志接口的封装
+// 本包的具名函数均视为封装；匿名函数视为调用方，因此 Middleware 等在闭包中
+// 输出的 access、panic 日志位置为 log/middleware.go，需要跳过的闭包应调用 Helper 标记。
+// 测试文件中的函数同样视为调用方
+func isWrapperFrame(frame runtime.Frame) bool {
+	if _, ok := helpers.Load(frame.Function); ok {
+		return true
+	}
+	switch pkg := funcPackage(frame.Function); pkg {
+	case "log", "log/slog":
+		return true
+	case logPackage:
+		return !strings.Contains(frame.Function[len(pkg):], ".func") &&
+			!strings.HasSuffix(frame.File, "_test.go")
+	}
+	return false
+}
+
+// funcPackage 返回函数名中的包路径，如 github.com/a/b.(*T).M 返回 github.com/a/b
+func funcPackage(function string) string {
+	slash := strings.LastIndexByte(function, '/')
+	if slash < 0 {
+		slash = 0
+	}
+	if dot := strings.IndexByte(function[slash:], '.'); dot >= 0 {
+		return function[:slash+dot]
+	}
+	return function
+}
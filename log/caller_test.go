@@ -0,0 +1,152 @@
+package log_test
+
+import (
+	"context"
+	"io/ioutil"
+	stdlog "log"
+	"reflect"
+	"runtime"
+	"testing"
+
+	"github.com/sanbsy/gopkg/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// logf 通过 Helper 标记的封装函数
+func logf(l *log.Logger, format string, args ...interface{}) {
+	log.Helper()
+	l.InfoF(format, args...)
+}
+
+// logSkip 通过 AddCallerSkip 跳过的封装函数
+func logSkip(l *log.Logger, message string) {
+	l.AddCallerSkip(1).Info(message)
+}
+
+func TestCaller(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	logger := log.NewLoggerWithCore(core, log.DebugLevel, zap.OnFatal(zapcore.WriteThenPanic))
+	defer log.ReplaceDefault(logger)()
+
+	ctx := log.WithLogger(context.Background(), logger.WithField("ctx", true))
+	fields := log.Fields{"k": "v"}
+	std := log.NewStdLogger(logger, log.InfoLevel, "")
+
+	// 每个用例写在一行内，期望的日志位置即函数字面量所在的行
+	cases := map[string]func(){
+		"Logger.Debug":            func() { logger.Debug("m") },
+		"Logger.DebugF":           func() { logger.DebugF("%s", "m") },
+		"Logger.DebugWithField":   func() { logger.DebugWithField("m", fields) },
+		"Logger.Info":             func() { logger.Info("m") },
+		"Logger.InfoF":            func() { logger.InfoF("%s", "m") },
+		"Logger.InfoWithField":    func() { logger.InfoWithField("m", fields) },
+		"Logger.Warn":             func() { logger.Warn("m") },
+		"Logger.WarnF":            func() { logger.WarnF("%s", "m") },
+		"Logger.WarnWithField":    func() { logger.WarnWithField("m", fields) },
+		"Logger.Error":            func() { logger.Error("m") },
+		"Logger.ErrorF":           func() { logger.ErrorF("%s", "m") },
+		"Logger.ErrorWithField":   func() { logger.ErrorWithField("m", fields) },
+		"Logger.Panic":            func() { assert.Panics(t, func() { logger.Panic("m") }) },
+		"Logger.PanicF":           func() { assert.Panics(t, func() { logger.PanicF("%s", "m") }) },
+		"Logger.PanicWithField":   func() { assert.Panics(t, func() { logger.PanicWithField("m", fields) }) },
+		"Logger.Fatal":            func() { assert.Panics(t, func() { logger.Fatal("m") }) },
+		"Logger.FatalF":           func() { assert.Panics(t, func() { logger.FatalF("%s", "m") }) },
+		"Logger.FatalWithField":   func() { assert.Panics(t, func() { logger.FatalWithField("m", fields) }) },
+		"Logger.Write":            func() { _, _ = logger.Write([]byte("m")) },
+		"Logger.WithField":        func() { logger.WithField("k", "v").SetName("n").WithScope("s").Info("m") },
+		"Logger.WithSampling":     func() { logger.WithSampling(nil).Info("m") },
+		"Logger.WithHooks":        func() { logger.WithHooks(func(log.Entry) {}).Info("m") },
+		"Debug":                   func() { log.Debug("m") },
+		"DebugF":                  func() { log.DebugF("%s", "m") },
+		"DebugWithFields":         func() { log.DebugWithFields("m", fields) },
+		"Info":                    func() { log.Info("m") },
+		"InfoF":                   func() { log.InfoF("%s", "m") },
+		"InfoWithFields":          func() { log.InfoWithFields("m", fields) },
+		"Warn":                    func() { log.Warn("m") },
+		"WarnF":                   func() { log.WarnF("%s", "m") },
+		"WarnWithFields":          func() { log.WarnWithFields("m", fields) },
+		"Error":                   func() { log.Error("m") },
+		"ErrorF":                  func() { log.ErrorF("%s", "m") },
+		"ErrorWithFields":         func() { log.ErrorWithFields("m", fields) },
+		"Panic":                   func() { assert.Panics(t, func() { log.Panic("m") }) },
+		"PanicF":                  func() { assert.Panics(t, func() { log.PanicF("%s", "m") }) },
+		"PanicWithFields":         func() { assert.Panics(t, func() { log.PanicWithFields("m", fields) }) },
+		"Fatal":                   func() { assert.Panics(t, func() { log.Fatal("m") }) },
+		"FatalF":                  func() { assert.Panics(t, func() { log.FatalF("%s", "m") }) },
+		"FatalWithFields":         func() { assert.Panics(t, func() { log.FatalWithFields("m", fields) }) },
+		"DebugCtx":                func() { log.DebugCtx(ctx, "m") },
+		"DebugCtxF":               func() { log.DebugCtxF(ctx, "%s", "m") },
+		"DebugCtxWithFields":      func() { log.DebugCtxWithFields(ctx, "m", fields) },
+		"InfoCtx":                 func() { log.InfoCtx(ctx, "m") },
+		"InfoCtxF":                func() { log.InfoCtxF(ctx, "%s", "m") },
+		"InfoCtxWithFields":       func() { log.InfoCtxWithFields(ctx, "m", fields) },
+		"WarnCtx":                 func() { log.WarnCtx(ctx, "m") },
+		"WarnCtxF":                func() { log.WarnCtxF(ctx, "%s", "m") },
+		"WarnCtxWithFields":       func() { log.WarnCtxWithFields(ctx, "m", fields) },
+		"ErrorCtx":                func() { log.ErrorCtx(ctx, "m") },
+		"ErrorCtxF":               func() { log.ErrorCtxF(ctx, "%s", "m") },
+		"ErrorCtxWithFields":      func() { log.ErrorCtxWithFields(ctx, "m", fields) },
+		"PanicCtx":                func() { assert.Panics(t, func() { log.PanicCtx(ctx, "m") }) },
+		"PanicCtxF":               func() { assert.Panics(t, func() { log.PanicCtxF(ctx, "%s", "m") }) },
+		"PanicCtxWithFields":      func() { assert.Panics(t, func() { log.PanicCtxWithFields(ctx, "m", fields) }) },
+		"FatalCtx":                func() { assert.Panics(t, func() { log.FatalCtx(ctx, "m") }) },
+		"FatalCtxF":               func() { assert.Panics(t, func() { log.FatalCtxF(ctx, "%s", "m") }) },
+		"FatalCtxWithFields":      func() { assert.Panics(t, func() { log.FatalCtxWithFields(ctx, "m", fields) }) },
+		"InfoCtx without logger":  func() { log.InfoCtx(context.Background(), "m") },
+		"NewStdLogger.Print":      func() { std.Print("m") },
+		"NewStdLogger.Printf":     func() { std.Printf("%s", "m") },
+		"NewStdLogger.Println":    func() { std.Println("m") },
+		"Helper":                  func() { logf(logger, "%s", "m") },
+		"AddCallerSkip":           func() { logSkip(logger, "m") },
+		"AddCallerSkip on Helper": func() { logf(logger.AddCallerSkip(0), "%s", "m") },
+	}
+
+	for name, fn := range cases {
+		logs.TakeAll()
+		fn()
+
+		entries := logs.TakeAll()
+		require.Len(t, entries, 1, name)
+		caller := entries[0].Caller
+		file, line := runtime.FuncForPC(reflect.ValueOf(fn).Pointer()).FileLine(reflect.ValueOf(fn).Pointer())
+		assert.True(t, caller.Defined, name)
+		assert.Equal(t, file, caller.File, name)
+		assert.Equal(t, line, caller.Line, name)
+	}
+}
+
+func TestCaller_RedirectStdLog(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	logger := log.NewLoggerWithCore(core, log.DebugLevel)
+	defer log.RedirectStdLog(logger, log.InfoLevel)()
+
+	fn := func() { stdlog.Printf("%s", "m") }
+	fn()
+
+	entries := logs.TakeAll()
+	require.Len(t, entries, 1)
+	_, line := runtime.FuncForPC(reflect.ValueOf(fn).Pointer()).FileLine(reflect.ValueOf(fn).Pointer())
+	assert.Equal(t, line, entries[0].Caller.Line)
+}
+
+func TestCaller_Disabled(t *testing.T) {
+	ass := assert.New(t)
+	path := t.TempDir() + "/app.log"
+	config := log.NewDefaultConfig(log.JSONFormat, log.InfoLevel)
+	config.OutputPaths = []string{path}
+	config.DisableCaller = true
+
+	logger, err := log.NewLoggerWithConfig(config)
+	require.Nil(t, err)
+	logger.Info("m")
+	logger.Close()
+
+	data, err := ioutil.ReadFile(path)
+	ass.Nil(err)
+	ass.Contains(string(data), `"@message":"m"`)
+	ass.NotContains(string(data), "@caller")
+}
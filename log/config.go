@@ -1,6 +1,9 @@
 package log
 
 import (
+	"encoding/json"
+	"sort"
+	"sync"
 	"time"
 
 	"go.uber.org/zap"
@@ -24,12 +27,26 @@ type (
 	EncoderConfig = zapcore.EncoderConfig
 	// SamplingOption Sampling 配置参数
 	SamplingOption struct {
-		Tick       time.Duration
-		Initial    int `json:"initial" yaml:"initial"`
-		Thereafter int `json:"thereafter" yaml:"thereafter"`
+		Tick       time.Duration `json:"tick" yaml:"tick" mapstructure:"tick"`
+		Initial    int           `json:"initial" yaml:"initial" mapstructure:"initial"`
+		Thereafter int           `json:"thereafter" yaml:"thereafter" mapstructure:"thereafter"`
 	}
 )
 
+// UnmarshalJSON 实现 json.Unmarshaler，Tick 支持 "1s" 形式的字符串
+func (opts *SamplingOption) UnmarshalJSON(data []byte) error {
+	type plain SamplingOption
+	aux := struct {
+		*plain
+		Tick Duration `json:"tick"`
+	}{plain: (*plain)(opts), Tick: Duration(opts.Tick)}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	opts.Tick = time.Duration(aux.Tick)
+	return nil
+}
+
 var defaultSamplingOption = &SamplingOption{
 	Tick:       time.Second,
 	Initial:    100,
@@ -67,14 +84,128 @@ func NewDefaultConfig(format string, level Level) Config {
 	}
 }
 
+// LoggerOption 创建 logger 的可选项
+type LoggerOption func(*loggerOptions)
+
+type loggerOptions struct {
+	async *AsyncOptions
+}
+
+// AsyncOutput 以异步方式写入 config.OutputPaths，opts 为 nil 时使用默认配置
+// 调用 Logger.Sync 时会将缓冲区中的日志全部写入，调用 Logger.Close 停止后台协程
+func AsyncOutput(opts *AsyncOptions) LoggerOption {
+	return func(o *loggerOptions) {
+		if opts == nil {
+			opts = &AsyncOptions{}
+		}
+		o.async = opts
+	}
+}
+
 // NewLoggerWithConfig 通过自定义配置创建 logger
-func NewLoggerWithConfig(config Config) (*Logger, error) {
-	logger, err := config.Build(zap.AddCallerSkip(CallerSkipOffset))
+func NewLoggerWithConfig(config Config, opts ...LoggerOption) (*Logger, error) {
+	var o loggerOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	levels := newLevelRegistry(config.Level)
+	logger, closeOut, err := buildLogger(config, levels, o.async)
 	if err != nil {
 		return nil, err
 	}
 	return &Logger{
-		level:  config.Level,
-		logger: logger,
+		level:     config.Level,
+		levels:    levels,
+		logger:    logger,
+		addCaller: !config.DisableCaller,
+		close:     closeOut,
 	}, nil
 }
+
+// NewLoggerWithCore 使用自定义 core 创建 logger，level 为初始的全局日志等级
+// core 自身的等级判断依然生效，通常用于测试或接入其他日志系统
+func NewLoggerWithCore(core zapcore.Core, level Level, opts ...zap.Option) *Logger {
+	atomic := zap.NewAtomicLevelAt(level)
+	levels := newLevelRegistry(atomic)
+	return &Logger{
+		level:     atomic,
+		levels:    levels,
+		logger:    zap.New(&levelCore{Core: core, levels: levels}, opts...),
+		addCaller: true,
+	}
+}
+
+// buildLogger 与 Config.Build 一致，但由 levels 判断日志等级以支持按模块设置，
+// async 不为 nil 时以 AsyncWriter 包装日志输出；返回的函数停止异步写入并关闭所有输出
+func buildLogger(config Config, levels *levelRegistry, async *AsyncOptions) (*zap.Logger, func(), error) {
+	sink, closeOut, err := zap.Open(config.OutputPaths...)
+	if err != nil {
+		return nil, nil, err
+	}
+	errSink, closeErr, err := zap.Open(config.ErrorOutputPaths...)
+	if err != nil {
+		closeOut()
+		return nil, nil, err
+	}
+
+	enc := newEncoder(config.Encoding, config.EncoderConfig)
+	closeSink := closeOut
+	if async != nil {
+		a := NewAsyncWriter(sink, async)
+		a.setEncoder(enc.Clone())
+		sink, closeSink = a, func() {
+			_ = a.Close()
+			closeOut()
+		}
+	}
+	var once sync.Once
+	closeAll := func() {
+		once.Do(func() {
+			closeSink()
+			closeErr()
+		})
+	}
+
+	core := &levelCore{
+		Core:   zapcore.NewCore(enc, sink, levels),
+		levels: levels,
+	}
+	zapOpts := []zap.Option{zap.ErrorOutput(errSink)}
+	if config.Development {
+		zapOpts = append(zapOpts, zap.Development())
+	}
+	if !config.DisableStacktrace {
+		stackLevel := zap.ErrorLevel
+		if config.Development {
+			stackLevel = zap.WarnLevel
+		}
+		zapOpts = append(zapOpts, zap.AddStacktrace(stackLevel))
+	}
+	if sampling := config.Sampling; sampling != nil {
+		zapOpts = append(zapOpts, zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+			return zapcore.NewSamplerWithOptions(core, time.Second, sampling.Initial, sampling.Thereafter)
+		}))
+	}
+	if len(config.InitialFields) > 0 {
+		keys := make([]string, 0, len(config.InitialFields))
+		for key := range config.InitialFields {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		fields := make([]zap.Field, 0, len(keys))
+		for _, key := range keys {
+			fields = append(fields, zap.Any(key, config.InitialFields[key]))
+		}
+		zapOpts = append(zapOpts, zap.Fields(fields...))
+	}
+	return zap.New(core, zapOpts...), closeAll, nil
+}
+
+// newEncoder 根据日志格式创建编码器，未知格式使用 TEXT 形式
+func newEncoder(format string, config EncoderConfig) zapcore.Encoder {
+	if format == JSONFormat {
+		return zapcore.NewJSONEncoder(config)
+	}
+	return zapcore.NewConsoleEncoder(config)
+}
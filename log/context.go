@@ -2,6 +2,13 @@ package log
 
 import (
 	"context"
+	"encoding/hex"
+	"sort"
+	"strings"
+
+	"github.com/sanbsy/gopkg/internal/idx"
+
+	"go.uber.org/zap"
 )
 
 // Key 内部key类型
@@ -10,6 +17,8 @@ type Key string
 const (
 	// ContextLoggerKey 在 context 中存储 logger 的 key
 	ContextLoggerKey Key = "context.logger.key"
+	// ContextFieldsKey 在 context 中存储 Fields 的 key
+	ContextFieldsKey Key = "context.fields.key"
 )
 
 // WithLogger 将 logger 存储到指定 context 中
@@ -17,12 +26,12 @@ func WithLogger(ctx context.Context, logger *Logger) context.Context {
 	return context.WithValue(ctx, ContextLoggerKey, logger)
 }
 
-// ExtractLogger 从 ctx 中提取 logger
+// ExtractLogger 从 ctx 中提取 logger，并附加 ctx 中的 Fields
 func ExtractLogger(ctx context.Context) *Logger {
 	if logger := extractLogger(ctx); logger != nil {
-		return logger
+		return logger.WithContext(ctx)
 	}
-	return defaultLogger.SetName("default")
+	return Default().SetName("default").WithContext(ctx)
 }
 
 func extractLogger(ctx context.Context) *Logger {
@@ -34,3 +43,98 @@ func extractLogger(ctx context.Context) *Logger {
 
 	return nil
 }
+
+// WithFields 将 fields 合并到 ctx 已有的 Fields 中，同名字段以新值为准
+// 通过 *Ctx 系列函数输出日志时会附加这些字段
+func WithFields(ctx context.Context, fields Fields) context.Context {
+	current := ExtractFields(ctx)
+	merged := make(Fields, len(current)+len(fields))
+	for key, value := range current {
+		merged[key] = value
+	}
+	for key, value := range fields {
+		merged[key] = value
+	}
+	return context.WithValue(ctx, ContextFieldsKey, merged)
+}
+
+// ExtractFields 获取 ctx 中的 Fields，返回值不可修改
+func ExtractFields(ctx context.Context) Fields {
+	if f, ok := ctx.Value(ContextFieldsKey).(Fields); ok {
+		return f
+	}
+	return nil
+}
+
+// NewLogId 生成唯一的 LogID
+func NewLogId() string {
+	return idx.NewID().String()
+}
+
+// WithLogId 将 LogID 写入 ctx，id 为空时自动生成
+func WithLogId(ctx context.Context, id string) context.Context {
+	if id == "" {
+		id = NewLogId()
+	}
+	return WithFields(ctx, Fields{IdKey: id})
+}
+
+// ExtractLogId 获取 ctx 中的 LogID，不存在时返回空字符串
+func ExtractLogId(ctx context.Context) string {
+	id, _ := ExtractFields(ctx)[IdKey].(string)
+	return id
+}
+
+// WithTraceparent 解析 W3C traceparent，将 trace id 与 span id 写入 ctx
+// traceparent 格式不正确时原样返回 ctx
+func WithTraceparent(ctx context.Context, traceparent string) context.Context {
+	traceId, spanId, ok := parseTraceparent(traceparent)
+	if !ok {
+		return ctx
+	}
+	return WithFields(ctx, Fields{
+		TraceIdKey: traceId,
+		SpanIdKey:  spanId,
+	})
+}
+
+// parseTraceparent 解析 version-traceid-parentid-flags 格式的 traceparent
+func parseTraceparent(s string) (traceId, spanId string, ok bool) {
+	parts := strings.Split(strings.TrimSpace(s), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || len(parts[1]) != 32 || len(parts[2]) != 16 || len(parts[3]) != 2 {
+		return "", "", false
+	}
+	if parts[0] == "ff" || parts[0] == "00" && len(parts) != 4 {
+		return "", "", false
+	}
+	for _, p := range parts[:4] {
+		if _, err := hex.DecodeString(p); err != nil || strings.ToLower(p) != p {
+			return "", "", false
+		}
+	}
+	if strings.Trim(parts[1], "0") == "" || strings.Trim(parts[2], "0") == "" {
+		return "", "", false
+	}
+	return parts[1], parts[2], true
+}
+
+// WithContext 派生一个 Logger，附加 ctx 中的 Fields
+// LogID 应在请求入口通过 Middleware 或 WithLogId(ctx, "") 生成，保证同一请求的日志共用
+func (l *Logger) WithContext(ctx context.Context) *Logger {
+	fields := ExtractFields(ctx)
+	if len(fields) == 0 {
+		return l
+	}
+
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	zf := make([]zap.Field, 0, len(keys))
+	for _, key := range keys {
+		zf = append(zf, zap.Any(key, fields[key]))
+	}
+	return l.derive(l.logger.With(zf...))
+}
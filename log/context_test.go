@@ -0,0 +1,58 @@
+package log
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithFields(t *testing.T) {
+	ass := assert.New(t)
+
+	out := &bytes.Buffer{}
+	logger := NewLogger(JSONFormat, InfoLevel).WithOutput(out, InfoLevel, JSONFormat)
+
+	ctx := WithLogger(context.Background(), logger)
+	ctx = WithLogId(ctx, "")
+	ctx = WithFields(ctx, Fields{"user": "u1"})
+	ctx = WithTraceparent(ctx, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	ass.NotEmpty(ExtractLogId(ctx))
+
+	fn := func() { InfoCtx(ctx, "hello") }
+	fn()
+	_, line := runtime.FuncForPC(reflect.ValueOf(fn).Pointer()).FileLine(reflect.ValueOf(fn).Pointer())
+	ass.Contains(out.String(), `"@logId":"`+ExtractLogId(ctx)+`"`)
+	ass.Contains(out.String(), `"@spanId":"00f067aa0ba902b7","@traceId":"4bf92f3577b34da6a3ce929d0e0e4736","user":"u1"`)
+	ass.Contains(out.String(), fmt.Sprintf(`"@caller":"log/context_test.go:%d"`, line))
+
+	ass.Equal(ctx, WithTraceparent(ctx, "00-00000000000000000000000000000000-00f067aa0ba902b7-01"))
+	ass.Equal(ctx, WithTraceparent(ctx, "invalid"))
+}
+
+func TestWithContext_SharedLogId(t *testing.T) {
+	ass := assert.New(t)
+
+	out := &bytes.Buffer{}
+	logger := NewLogger(JSONFormat, InfoLevel).WithOutput(out, InfoLevel, JSONFormat)
+
+	// 同一请求内的多次输出共用入口处生成的 LogID
+	ctx := WithLogId(WithLogger(context.Background(), logger), "")
+	DebugCtx(ctx, "hidden")
+	InfoCtx(ctx, "first")
+	ExtractLogger(WithFields(ctx, Fields{"user": "u1"})).Info("second")
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	ass.Len(lines, 2)
+	ass.Contains(lines[0], `"@message":"first","@logId":"`+ExtractLogId(ctx)+`"`)
+	ass.Contains(lines[1], `"@message":"second","@logId":"`+ExtractLogId(ctx)+`","user":"u1"`)
+
+	out.Reset()
+	InfoCtx(WithLogger(context.Background(), logger), "no id")
+	ass.NotContains(out.String(), IdKey)
+}
@@ -0,0 +1,167 @@
+package log
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// 默认的统计窗口
+var defaultCounterWindows = []time.Duration{time.Minute, 5 * time.Minute, 15 * time.Minute}
+
+// counterResolution 滑动窗口的统计精度
+const counterResolution = time.Second
+
+type (
+	// Counter 按日志等级与 Logger Name 统计日志数量的 Hook
+	Counter struct {
+		windows []time.Duration
+		size    int64
+
+		mu       sync.Mutex
+		counters map[counterKey]*slidingCounter
+	}
+
+	// CounterStat 日志数量统计
+	CounterStat struct {
+		Level      Level  `json:"level"`
+		LoggerName string `json:"logger"`
+		// Total 累计数量
+		Total int64 `json:"total"`
+		// Windows 各统计窗口内的数量，key 为窗口时长
+		Windows map[string]int64 `json:"windows"`
+	}
+
+	counterKey struct {
+		level Level
+		name  string
+	}
+
+	// slidingCounter 以秒为单位的环形计数
+	slidingCounter struct {
+		total   int64
+		buckets []int64
+		slots   []int64
+	}
+)
+
+// NewCounter 创建 Counter，windows 为空时统计 1m、5m、15m 内的数量
+func NewCounter(windows ...time.Duration) *Counter {
+	if len(windows) == 0 {
+		windows = defaultCounterWindows
+	}
+	c := &Counter{
+		windows:  append([]time.Duration{}, windows...),
+		counters: make(map[counterKey]*slidingCounter),
+	}
+	for _, w := range c.windows {
+		if n := int64(w / counterResolution); n > c.size {
+			c.size = n
+		}
+	}
+	if c.size == 0 {
+		c.size = 1
+	}
+	return c
+}
+
+// Hook 实现 Hook，通过 Logger.WithHooks(counter.Hook) 使用
+func (c *Counter) Hook(entry Entry) {
+	slot := c.slot(entry.Time)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	key := counterKey{level: entry.Level, name: entry.LoggerName}
+	sc, ok := c.counters[key]
+	if !ok {
+		sc = &slidingCounter{
+			buckets: make([]int64, c.size),
+			slots:   make([]int64, c.size),
+		}
+		c.counters[key] = sc
+	}
+	sc.total++
+	i := slot % c.size
+	if sc.slots[i] != slot {
+		sc.slots[i] = slot
+		sc.buckets[i] = 0
+	}
+	sc.buckets[i]++
+}
+
+// Count 获取指定等级与 Logger Name 在 window 内的日志数量
+func (c *Counter) Count(level Level, name string, window time.Duration) int64 {
+	now := c.slot(currentTime())
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	sc, ok := c.counters[counterKey{level: level, name: name}]
+	if !ok {
+		return 0
+	}
+	return c.count(sc, now, window)
+}
+
+func (c *Counter) count(sc *slidingCounter, now int64, window time.Duration) int64 {
+	n := int64(window / counterResolution)
+	if n > c.size {
+		n = c.size
+	}
+	var sum int64
+	for slot := now - n + 1; slot <= now; slot++ {
+		if i := slot % c.size; sc.slots[i] == slot {
+			sum += sc.buckets[i]
+		}
+	}
+	return sum
+}
+
+func (c *Counter) slot(t time.Time) int64 {
+	if t.IsZero() {
+		t = currentTime()
+	}
+	return t.UnixNano() / int64(counterResolution)
+}
+
+// Stats 获取所有统计，按 Logger Name 与日志等级排序
+func (c *Counter) Stats() []CounterStat {
+	now := c.slot(currentTime())
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	stats := make([]CounterStat, 0, len(c.counters))
+	for key, sc := range c.counters {
+		stat := CounterStat{
+			Level:      key.level,
+			LoggerName: key.name,
+			Total:      sc.total,
+			Windows:    make(map[string]int64, len(c.windows)),
+		}
+		for _, w := range c.windows {
+			stat.Windows[w.String()] = c.count(sc, now, w)
+		}
+		stats = append(stats, stat)
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].LoggerName != stats[j].LoggerName {
+			return stats[i].LoggerName < stats[j].LoggerName
+		}
+		return stats[i].Level < stats[j].Level
+	})
+	return stats
+}
+
+// Reset 清空统计
+func (c *Counter) Reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.counters = make(map[counterKey]*slidingCounter)
+}
+
+// StatsHandler Web handler，以 JSON 输出日志数量统计
+func (c *Counter) StatsHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(c.Stats())
+}
@@ -0,0 +1,410 @@
+package log
+
+import (
+	"container/list"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/sanbsy/gopkg/bufferpool"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	// RepeatedKey 合并后的日志中，被合并的重复日志条数
+	RepeatedKey = "repeated"
+	// SuppressedKey 限流汇总日志中，被丢弃的日志条数
+	SuppressedKey = "suppressed"
+)
+
+type (
+	// DedupOptions 重复日志合并配置
+	// 等级、Logger Name、信息以及 Keys 中的字段都相同的日志视为重复日志
+	DedupOptions struct {
+		// 合并窗口，默认 1s；窗口内的第一条日志立即输出，
+		// 其余重复日志在窗口结束时合并为一条，附加 repeated 字段记录条数
+		Window Duration `json:"window" yaml:"window" mapstructure:"window"`
+
+		// 参与比较的字段，为空时不比较字段
+		Keys []string `json:"keys" yaml:"keys" mapstructure:"keys"`
+	}
+
+	// RateLimitOptions 日志限流配置，按 key 使用令牌桶限流
+	RateLimitOptions struct {
+		// 每个 key 每秒允许输出的日志条数，默认 10
+		Rate float64 `json:"rate" yaml:"rate" mapstructure:"rate"`
+
+		// 令牌桶容量，默认与 Rate 相同
+		Burst int `json:"burst" yaml:"burst" mapstructure:"burst"`
+
+		// 作为限流 key 的字段，为空时按 Logger Name 与调用位置限流
+		Keys []string `json:"keys" yaml:"keys" mapstructure:"keys"`
+
+		// 出现丢弃后输出汇总日志的间隔，默认 10s；
+		// 汇总日志为最后一条被丢弃的日志，附加 suppressed 字段记录丢弃条数
+		Interval Duration `json:"interval" yaml:"interval" mapstructure:"interval"`
+
+		// 最多保留的 key 数量，超出时淘汰最久未使用的 key 并立即输出其汇总日志，默认 4096
+		MaxKeys int `json:"max_keys" yaml:"max_keys" mapstructure:"max_keys"`
+	}
+)
+
+// timer 可停止的定时器
+type timer interface {
+	Stop() bool
+}
+
+// afterFunc 创建定时器，便于测试替换
+var afterFunc = func(d time.Duration, f func()) timer {
+	return time.AfterFunc(d, f)
+}
+
+// pendingEntry 等待输出的合并或汇总日志
+type pendingEntry struct {
+	// core 下层 core，包含派生时附加的字段
+	core   zapcore.Core
+	ent    zapcore.Entry
+	fields []zapcore.Field
+	count  int
+	timer  timer
+}
+
+// write 附加计数字段后输出
+func (e *pendingEntry) write(key string) {
+	if ice := e.core.Check(e.ent, nil); ice != nil {
+		ice.Write(append(e.fields, zap.Int(key, e.count))...)
+	}
+}
+
+// dedupState 由同一 Logger 派生的 dedupCore 共享
+type dedupState struct {
+	window time.Duration
+	keys   []string
+
+	mu      sync.Mutex
+	pending map[string]*pendingEntry
+}
+
+func (s *dedupState) flush(key string, e *pendingEntry) {
+	s.mu.Lock()
+	if s.pending[key] != e {
+		s.mu.Unlock()
+		return
+	}
+	delete(s.pending, key)
+	s.mu.Unlock()
+
+	if e.count > 0 {
+		e.write(RepeatedKey)
+	}
+}
+
+func (s *dedupState) flushAll() {
+	s.mu.Lock()
+	pending := s.pending
+	s.pending = make(map[string]*pendingEntry)
+	s.mu.Unlock()
+
+	for _, e := range pending {
+		e.timer.Stop()
+		if e.count > 0 {
+			e.write(RepeatedKey)
+		}
+	}
+}
+
+// dedupCore 合并窗口内的重复日志，panic 及以上等级的日志不合并
+type dedupCore struct {
+	zapcore.Core
+	state   *dedupState
+	context []zapcore.Field
+}
+
+func newDedupCore(core zapcore.Core, opts *DedupOptions) *dedupCore {
+	if opts == nil {
+		opts = &DedupOptions{}
+	}
+	window := time.Duration(opts.Window)
+	if window <= 0 {
+		window = time.Second
+	}
+	return &dedupCore{Core: core, state: &dedupState{
+		window:  window,
+		keys:    opts.Keys,
+		pending: make(map[string]*pendingEntry),
+	}}
+}
+
+func (c *dedupCore) With(fields []zapcore.Field) zapcore.Core {
+	context := make([]zapcore.Field, 0, len(c.context)+len(fields))
+	context = append(append(context, c.context...), fields...)
+	return &dedupCore{Core: c.Core.With(fields), state: c.state, context: context}
+}
+
+func (c *dedupCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c *dedupCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	ice := c.Core.Check(ent, nil)
+	if ice == nil {
+		return nil
+	}
+	if ent.Level > ErrorLevel {
+		ice.Write(fields...)
+		return nil
+	}
+
+	s := c.state
+	key := entryKey(ent, ent.Message, s.keys, c.context, fields)
+	s.mu.Lock()
+	if e, ok := s.pending[key]; ok {
+		e.core, e.ent, e.fields = c.Core, ent, copyFields(fields)
+		e.count++
+		s.mu.Unlock()
+		return nil
+	}
+	e := &pendingEntry{}
+	e.timer = afterFunc(s.window, func() { s.flush(key, e) })
+	s.pending[key] = e
+	s.mu.Unlock()
+
+	ice.Write(fields...)
+	return nil
+}
+
+// Sync 输出等待合并的日志后同步下层 core
+func (c *dedupCore) Sync() error {
+	c.state.flushAll()
+	return c.Core.Sync()
+}
+
+// WithDedup 派生一个 Logger，合并窗口内的重复日志
+func (l *Logger) WithDedup(opts *DedupOptions) *Logger {
+	logger := l.logger.WithOptions(zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+		return newDedupCore(core, opts)
+	}))
+	return l.derive(logger)
+}
+
+// tokenBucket 单个 key 的令牌桶
+type tokenBucket struct {
+	key    string
+	tokens float64
+	last   time.Time
+	// suppressed 最后一条被丢弃的日志与丢弃条数
+	suppressed *pendingEntry
+}
+
+// rateLimitState 由同一 Logger 派生的 rateLimitCore 共享
+type rateLimitState struct {
+	rate     float64
+	burst    float64
+	keys     []string
+	interval time.Duration
+	maxKeys  int
+
+	mu      sync.Mutex
+	buckets map[string]*list.Element
+	// lru 按最近使用排列的令牌桶，最近使用的在前
+	lru *list.List
+}
+
+// allow 消耗一个令牌，返回是否允许输出
+func (s *rateLimitState) allow(b *tokenBucket, now time.Time) bool {
+	b.tokens += now.Sub(b.last).Seconds() * s.rate
+	if b.tokens > s.burst {
+		b.tokens = s.burst
+	}
+	b.last = now
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// bucket 获取 key 的令牌桶并标记为最近使用，超出数量限制时返回被淘汰的令牌桶
+// 调用方需持有锁
+func (s *rateLimitState) bucket(key string, now time.Time) (*tokenBucket, *tokenBucket) {
+	if elem, ok := s.buckets[key]; ok {
+		s.lru.MoveToFront(elem)
+		return elem.Value.(*tokenBucket), nil
+	}
+
+	b := &tokenBucket{key: key, tokens: s.burst, last: now}
+	s.buckets[key] = s.lru.PushFront(b)
+	if s.lru.Len() <= s.maxKeys {
+		return b, nil
+	}
+	evicted := s.lru.Remove(s.lru.Back()).(*tokenBucket)
+	delete(s.buckets, evicted.key)
+	return b, evicted
+}
+
+func (s *rateLimitState) flush(b *tokenBucket) {
+	s.mu.Lock()
+	e := b.suppressed
+	b.suppressed = nil
+	s.mu.Unlock()
+
+	if e != nil {
+		e.timer.Stop()
+		e.write(SuppressedKey)
+	}
+}
+
+func (s *rateLimitState) flushAll() {
+	s.mu.Lock()
+	buckets := make([]*tokenBucket, 0, s.lru.Len())
+	for elem := s.lru.Front(); elem != nil; elem = elem.Next() {
+		buckets = append(buckets, elem.Value.(*tokenBucket))
+	}
+	s.mu.Unlock()
+
+	for _, b := range buckets {
+		s.flush(b)
+	}
+}
+
+// rateLimitCore 按 key 限流，panic 及以上等级的日志不限流
+type rateLimitCore struct {
+	zapcore.Core
+	state   *rateLimitState
+	context []zapcore.Field
+}
+
+func newRateLimitCore(core zapcore.Core, opts *RateLimitOptions) *rateLimitCore {
+	if opts == nil {
+		opts = &RateLimitOptions{}
+	}
+	s := &rateLimitState{
+		rate:     opts.Rate,
+		burst:    float64(opts.Burst),
+		keys:     opts.Keys,
+		interval: time.Duration(opts.Interval),
+		maxKeys:  opts.MaxKeys,
+		buckets:  make(map[string]*list.Element),
+		lru:      list.New(),
+	}
+	if s.rate <= 0 {
+		s.rate = 10
+	}
+	if s.burst <= 0 {
+		s.burst = s.rate
+	}
+	if s.interval <= 0 {
+		s.interval = 10 * time.Second
+	}
+	if s.maxKeys <= 0 {
+		s.maxKeys = 4096
+	}
+	return &rateLimitCore{Core: core, state: s}
+}
+
+func (c *rateLimitCore) With(fields []zapcore.Field) zapcore.Core {
+	context := make([]zapcore.Field, 0, len(c.context)+len(fields))
+	context = append(append(context, c.context...), fields...)
+	return &rateLimitCore{Core: c.Core.With(fields), state: c.state, context: context}
+}
+
+func (c *rateLimitCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c *rateLimitCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	ice := c.Core.Check(ent, nil)
+	if ice == nil {
+		return nil
+	}
+	if ent.Level > ErrorLevel {
+		ice.Write(fields...)
+		return nil
+	}
+
+	s := c.state
+	key := ent.Caller.String()
+	if len(s.keys) > 0 || !ent.Caller.Defined {
+		key = ""
+	}
+	key = entryKey(zapcore.Entry{LoggerName: ent.LoggerName}, key, s.keys, c.context, fields)
+	now := currentTime()
+
+	s.mu.Lock()
+	b, evicted := s.bucket(key, now)
+	if evicted != nil && evicted.suppressed != nil {
+		// 被淘汰的 key 不再有机会输出汇总日志，立即输出
+		defer s.flush(evicted)
+	}
+	if s.allow(b, now) {
+		s.mu.Unlock()
+		ice.Write(fields...)
+		return nil
+	}
+
+	e := b.suppressed
+	if e == nil {
+		e = &pendingEntry{}
+		e.timer = afterFunc(s.interval, func() { s.flush(b) })
+		b.suppressed = e
+	}
+	e.core, e.ent, e.fields = c.Core, ent, copyFields(fields)
+	e.count++
+	s.mu.Unlock()
+	return nil
+}
+
+// Sync 输出限流汇总日志后同步下层 core
+func (c *rateLimitCore) Sync() error {
+	c.state.flushAll()
+	return c.Core.Sync()
+}
+
+// WithRateLimit 派生一个 Logger，按 key 限制日志输出速率，
+// 被丢弃的日志定期汇总为一条附加 suppressed 字段的日志
+func (l *Logger) WithRateLimit(opts *RateLimitOptions) *Logger {
+	logger := l.logger.WithOptions(zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+		return newRateLimitCore(core, opts)
+	}))
+	return l.derive(logger)
+}
+
+// entryKey 由日志等级、Logger Name、text 以及 keys 字段的值组成 key
+func entryKey(ent zapcore.Entry, text string, keys []string, context, fields []zapcore.Field) string {
+	buf := bufferpool.Get()
+	defer buf.Free()
+
+	buf.WriteString(ent.Level.String())
+	buf.WriteByte(0)
+	buf.WriteString(ent.LoggerName)
+	buf.WriteByte(0)
+	buf.WriteString(text)
+	if len(keys) > 0 {
+		enc := zapcore.NewMapObjectEncoder()
+		for _, f := range context {
+			f.AddTo(enc)
+		}
+		for _, f := range fields {
+			f.AddTo(enc)
+		}
+		for _, key := range keys {
+			buf.WriteByte(0)
+			if v, ok := enc.Fields[key]; ok {
+				buf.WriteString(fmt.Sprint(v))
+			}
+		}
+	}
+	// buf.String 与缓冲区共享内存，key 需要复制
+	return string(buf.Bytes())
+}
+
+func copyFields(fields []zapcore.Field) []zapcore.Field {
+	return append([]zapcore.Field(nil), fields...)
+}
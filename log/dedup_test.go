@@ -0,0 +1,204 @@
+package log
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestLogger_WithDedup(t *testing.T) {
+	ass := assert.New(t)
+	core, logs := observer.New(zapcore.DebugLevel)
+	logger := NewLoggerWithCore(core, DebugLevel).WithDedup(&DedupOptions{Window: Duration(time.Hour), Keys: []string{"user"}})
+
+	for i := 0; i < 3; i++ {
+		logger.WithField("user", 1).InfoWithField("login failed", Fields{"attempt": i})
+	}
+	logger.WithField("user", 2).Info("login failed")
+	logger.Info("other")
+	logger.WithField("user", 1).Warn("login failed")
+
+	entries := logs.TakeAll()
+	require.Len(t, entries, 4)
+	ass.Equal(int64(1), entries[0].ContextMap()["user"])
+	ass.Equal(int64(2), entries[1].ContextMap()["user"])
+	ass.Equal("other", entries[2].Message)
+	ass.Equal(WarnLevel, entries[3].Level)
+
+	logger.Sync()
+	entries = logs.TakeAll()
+	require.Len(t, entries, 1)
+	ass.Equal("login failed", entries[0].Message)
+	ass.Equal(map[string]interface{}{"user": int64(1), "attempt": int64(2), RepeatedKey: int64(2)}, entries[0].ContextMap())
+
+	// 合并后开始新的窗口
+	logger.WithField("user", 1).Info("login failed")
+	ass.Equal(1, logs.Len())
+	logger.Sync()
+	ass.Equal(1, logs.Len())
+}
+
+// fakeTimer 手动触发的定时器
+type fakeTimer struct {
+	f       func()
+	stopped bool
+}
+
+func (t *fakeTimer) Stop() bool {
+	t.stopped = true
+	return true
+}
+
+func setAfterFunc(t *testing.T) *[]*fakeTimer {
+	var timers []*fakeTimer
+	origin := afterFunc
+	afterFunc = func(_ time.Duration, f func()) timer {
+		ft := &fakeTimer{f: f}
+		timers = append(timers, ft)
+		return ft
+	}
+	t.Cleanup(func() { afterFunc = origin })
+	return &timers
+}
+
+func TestLogger_WithDedup_Window(t *testing.T) {
+	ass := assert.New(t)
+	timers := setAfterFunc(t)
+	core, logs := observer.New(zapcore.DebugLevel)
+	logger := NewLoggerWithCore(core, DebugLevel).WithDedup(&DedupOptions{Window: Duration(time.Second)})
+
+	for i := 0; i < 3; i++ {
+		logger.Error("timeout")
+	}
+	ass.Equal(1, logs.Len())
+	require.Len(t, *timers, 1)
+
+	(*timers)[0].f()
+	require.Equal(t, 2, logs.Len())
+	ass.Equal(int64(2), logs.All()[1].ContextMap()[RepeatedKey])
+
+	// 窗口结束后开始新的窗口
+	logger.Error("timeout")
+	ass.Equal(3, logs.Len())
+	ass.Len(*timers, 2)
+}
+
+func TestLogger_WithRateLimit_Interval(t *testing.T) {
+	ass := assert.New(t)
+	timers := setAfterFunc(t)
+	now := time.Date(2020, 5, 1, 10, 0, 0, 0, time.UTC)
+	setCurrentTime(t, &now)
+
+	core, logs := observer.New(zapcore.DebugLevel)
+	logger := NewLoggerWithCore(core, DebugLevel).WithRateLimit(&RateLimitOptions{Rate: 1, Keys: []string{"k"}})
+	for i := 0; i < 4; i++ {
+		logger.InfoWithField("m", Fields{"k": 1})
+	}
+	ass.Equal(1, logs.Len())
+	require.Len(t, *timers, 1)
+
+	(*timers)[0].f()
+	require.Equal(t, 2, logs.Len())
+	ass.Equal(int64(3), logs.All()[1].ContextMap()[SuppressedKey])
+}
+
+func TestLogger_WithRateLimit(t *testing.T) {
+	ass := assert.New(t)
+	now := time.Date(2020, 5, 1, 10, 0, 0, 0, time.UTC)
+	setCurrentTime(t, &now)
+
+	core, logs := observer.New(zapcore.DebugLevel)
+	logger := NewLoggerWithCore(core, DebugLevel).WithRateLimit(&RateLimitOptions{Rate: 1, Burst: 2, Interval: Duration(time.Hour)})
+
+	// 同一调用位置共享令牌桶
+	info := func(message string) { logger.Info(message) }
+	for _, message := range []string{"message 0", "message 1", "message 2", "message 3", "message 4"} {
+		info(message)
+	}
+	ass.Equal([]string{"message 0", "message 1"}, messages(logs.TakeAll()))
+
+	now = now.Add(time.Second)
+	info("message 5")
+	info("message 6")
+	ass.Equal([]string{"message 5"}, messages(logs.TakeAll()))
+
+	// 其他调用位置不受影响
+	logger.Info("elsewhere")
+	ass.Equal(1, logs.Len())
+	logs.TakeAll()
+
+	logger.Sync()
+	entries := logs.TakeAll()
+	require.Len(t, entries, 1)
+	ass.Equal("message 6", entries[0].Message)
+	ass.Equal(int64(4), entries[0].ContextMap()[SuppressedKey])
+
+	logger.Sync()
+	ass.Equal(0, logs.Len())
+}
+
+func TestLogger_WithRateLimit_Keys(t *testing.T) {
+	ass := assert.New(t)
+	now := time.Date(2020, 5, 1, 10, 0, 0, 0, time.UTC)
+	setCurrentTime(t, &now)
+
+	core, logs := observer.New(zapcore.DebugLevel)
+	logger := NewLoggerWithCore(core, DebugLevel).WithRateLimit(&RateLimitOptions{Rate: 1, Keys: []string{"tenant"}, Interval: Duration(time.Hour)})
+
+	for i := 0; i < 3; i++ {
+		logger.InfoWithField("a", Fields{"tenant": "x"})
+		logger.WarnWithField("b", Fields{"tenant": "y"})
+	}
+	ass.Equal([]string{"a", "b"}, messages(logs.TakeAll()))
+
+	logger.Sync()
+	entries := logs.TakeAll()
+	require.Len(t, entries, 2)
+	for _, e := range entries {
+		ass.Equal(int64(2), e.ContextMap()[SuppressedKey])
+	}
+}
+
+func TestLogger_WithRateLimit_MaxKeys(t *testing.T) {
+	ass := assert.New(t)
+	now := time.Date(2020, 5, 1, 10, 0, 0, 0, time.UTC)
+	setCurrentTime(t, &now)
+
+	core, logs := observer.New(zapcore.DebugLevel)
+	logger := NewLoggerWithCore(core, DebugLevel).WithRateLimit(&RateLimitOptions{
+		Rate: 1, Keys: []string{"id"}, Interval: Duration(time.Hour), MaxKeys: 2,
+	})
+	state := logger.logger.Core().(*rateLimitCore).state
+
+	logger.InfoWithField("m", Fields{"id": 1})
+	logger.InfoWithField("m", Fields{"id": 1})
+	logger.InfoWithField("m", Fields{"id": 2})
+	ass.Equal(2, logs.Len())
+	logs.TakeAll()
+
+	// 淘汰最久未使用的 id=1，并立即输出其汇总日志
+	logger.InfoWithField("m", Fields{"id": 3})
+	entries := logs.TakeAll()
+	require.Len(t, entries, 2)
+	ass.Equal(int64(3), entries[0].ContextMap()["id"])
+	ass.Equal(int64(1), entries[1].ContextMap()["id"])
+	ass.Equal(int64(1), entries[1].ContextMap()[SuppressedKey])
+
+	for i := 4; i < 100; i++ {
+		logger.InfoWithField("m", Fields{"id": i})
+	}
+	ass.Equal(2, state.lru.Len())
+	ass.Len(state.buckets, 2)
+}
+
+func messages(entries []observer.LoggedEntry) []string {
+	s := make([]string, 0, len(entries))
+	for _, e := range entries {
+		s = append(s, e.Message)
+	}
+	return s
+}
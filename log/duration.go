@@ -0,0 +1,54 @@
+package log
+
+import (
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"github.com/sanbsy/gopkg/errors"
+)
+
+// Duration 配置文件中的时间间隔，支持 "1s"、"500ms" 形式的字符串，
+// 以及以纳秒为单位的整数
+type Duration time.Duration
+
+// String 实现 fmt.Stringer
+func (d Duration) String() string {
+	return time.Duration(d).String()
+}
+
+// MarshalText 实现 encoding.TextMarshaler
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+// UnmarshalText 实现 encoding.TextUnmarshaler
+func (d *Duration) UnmarshalText(text []byte) error {
+	if n, err := strconv.ParseInt(string(text), 10, 64); err == nil {
+		*d = Duration(n)
+		return nil
+	}
+	v, err := time.ParseDuration(string(text))
+	if err != nil {
+		return errors.Wrapf(err, "log: invalid duration %q", text)
+	}
+	*d = Duration(v)
+	return nil
+}
+
+// UnmarshalJSON 实现 json.Unmarshaler
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	if len(data) > 0 && data[0] == '"' {
+		var s string
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+		return d.UnmarshalText([]byte(s))
+	}
+	var v int64
+	if err := json.Unmarshal(data, &v); err != nil {
+		return errors.Wrapf(err, "log: invalid duration %s", data)
+	}
+	*d = Duration(v)
+	return nil
+}
@@ -0,0 +1,83 @@
+package log
+
+import (
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+type (
+	// Entry 传递给 Hook 的日志信息
+	Entry struct {
+		Level      Level
+		LoggerName string
+		Message    string
+		Time       time.Time
+		// Fields 日志字段，包括通过 WithField 等附加的字段
+		Fields Fields
+	}
+
+	// Hook 日志钩子，每条实际输出的日志调用一次，应尽快返回
+	Hook func(entry Entry)
+)
+
+// hookCore 日志写入下层 core 前调用 hooks
+type hookCore struct {
+	zapcore.Core
+	hooks   []Hook
+	context []zapcore.Field
+}
+
+func (c *hookCore) With(fields []zapcore.Field) zapcore.Core {
+	context := make([]zapcore.Field, 0, len(c.context)+len(fields))
+	context = append(append(context, c.context...), fields...)
+	return &hookCore{Core: c.Core.With(fields), hooks: c.hooks, context: context}
+}
+
+func (c *hookCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c *hookCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	ice := c.Core.Check(ent, nil)
+	if ice == nil {
+		return nil
+	}
+
+	enc := zapcore.NewMapObjectEncoder()
+	for _, f := range c.context {
+		f.AddTo(enc)
+	}
+	for _, f := range fields {
+		f.AddTo(enc)
+	}
+	entry := Entry{
+		Level:      ent.Level,
+		LoggerName: ent.LoggerName,
+		Message:    ent.Message,
+		Time:       ent.Time,
+		Fields:     enc.Fields,
+	}
+	for _, hook := range c.hooks {
+		hook(entry)
+	}
+
+	ice.Write(fields...)
+	return nil
+}
+
+// WithHooks 派生一个 Logger，每条输出的日志都会调用 hooks
+// Entry.Fields 仅包含派生之后附加的字段
+func (l *Logger) WithHooks(hooks ...Hook) *Logger {
+	if len(hooks) == 0 {
+		return l
+	}
+	logger := l.logger.WithOptions(zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+		return &hookCore{Core: core, hooks: hooks}
+	}))
+	return l.derive(logger)
+}
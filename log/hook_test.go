@@ -0,0 +1,63 @@
+package log
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLogger_WithHooks(t *testing.T) {
+	ass := assert.New(t)
+	var entries []Entry
+	logger := NewLogger(JSONFormat, FatalLevel).
+		WithOutput(ioutil.Discard, InfoLevel, JSONFormat).
+		WithHooks(func(e Entry) { entries = append(entries, e) })
+
+	logger.Debug("hidden")
+	logger.SetName("sqler").WithScope("tx").ErrorWithField("commit failed", Fields{"rows": 1})
+
+	ass.Len(entries, 1)
+	ass.Equal(ErrorLevel, entries[0].Level)
+	ass.Equal("sqler", entries[0].LoggerName)
+	ass.Equal("commit failed", entries[0].Message)
+	ass.Equal(Fields{ScopeKey: "tx", "rows": int64(1)}, entries[0].Fields)
+}
+
+func TestCounter(t *testing.T) {
+	ass := assert.New(t)
+	now := time.Date(2020, 5, 1, 10, 0, 0, 0, time.UTC)
+	setCurrentTime(t, &now)
+
+	c := NewCounter(time.Minute, 5*time.Minute)
+	for i := 0; i < 3; i++ {
+		c.Hook(Entry{Level: ErrorLevel, LoggerName: "sqler", Time: now.Add(-4 * time.Minute)})
+	}
+	c.Hook(Entry{Level: ErrorLevel, LoggerName: "sqler", Time: now.Add(-10 * time.Second)})
+	c.Hook(Entry{Level: InfoLevel, LoggerName: "sqler", Time: now})
+	c.Hook(Entry{Level: WarnLevel, Time: now})
+
+	ass.Equal(int64(1), c.Count(ErrorLevel, "sqler", time.Minute))
+	ass.Equal(int64(4), c.Count(ErrorLevel, "sqler", 5*time.Minute))
+	ass.Equal(int64(0), c.Count(ErrorLevel, "store", time.Minute))
+
+	now = now.Add(2 * time.Minute)
+	ass.Equal(int64(0), c.Count(ErrorLevel, "sqler", time.Minute))
+	ass.Equal(int64(1), c.Count(ErrorLevel, "sqler", 5*time.Minute))
+
+	w := httptest.NewRecorder()
+	c.StatsHandler(w, httptest.NewRequest("GET", "/log/stats", nil))
+	var stats []CounterStat
+	ass.Nil(json.Unmarshal(w.Body.Bytes(), &stats))
+	ass.Equal([]CounterStat{
+		{Level: WarnLevel, Total: 1, Windows: map[string]int64{"1m0s": 0, "5m0s": 1}},
+		{Level: InfoLevel, LoggerName: "sqler", Total: 1, Windows: map[string]int64{"1m0s": 0, "5m0s": 1}},
+		{Level: ErrorLevel, LoggerName: "sqler", Total: 4, Windows: map[string]int64{"1m0s": 0, "5m0s": 1}},
+	}, stats)
+
+	c.Reset()
+	ass.Empty(c.Stats())
+}
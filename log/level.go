@@ -0,0 +1,294 @@
+package log
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// LevelOverride 按模块设置的日志等级
+type LevelOverride struct {
+	// Key 匹配 Logger Name 或 Scope，支持以 . / : 分隔的前缀匹配
+	Key   string `json:"key"`
+	Level Level  `json:"level"`
+	// ExpireAt 到期后自动恢复，为空表示不过期
+	ExpireAt *time.Time `json:"expireAt,omitempty"`
+}
+
+type levelOverride struct {
+	level  Level
+	expire time.Time
+	timer  *time.Timer
+}
+
+// levelRegistry 全局日志等级与按模块设置的日志等级，由同一 logger 派生的 logger 共享
+// 作为 core 的 LevelEnabler 时，只要任一等级允许即放行，由 levelCore 精确判断
+type levelRegistry struct {
+	global zap.AtomicLevel
+
+	mu        sync.RWMutex
+	overrides map[string]*levelOverride
+	// size 当前 override 数量，为 0 时跳过匹配
+	size int32
+	// min 所有 override 中的最低等级
+	min Level
+}
+
+func newLevelRegistry(global zap.AtomicLevel) *levelRegistry {
+	return &levelRegistry{
+		global:    global,
+		overrides: make(map[string]*levelOverride),
+	}
+}
+
+// Enabled 实现 zapcore.LevelEnabler
+func (r *levelRegistry) Enabled(level Level) bool {
+	if r.global.Enabled(level) {
+		return true
+	}
+	if atomic.LoadInt32(&r.size) == 0 {
+		return false
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return level >= r.min
+}
+
+// levelFor 获取 name 与 scope 匹配的日志等级，优先使用最长的匹配
+func (r *levelRegistry) levelFor(name, scope string) (Level, bool) {
+	if atomic.LoadInt32(&r.size) == 0 {
+		return 0, false
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var (
+		level   Level
+		matched = -1
+	)
+	for key, o := range r.overrides {
+		if len(key) > matched && (matchKey(key, scope) || matchKey(key, name)) {
+			level, matched = o.level, len(key)
+		}
+	}
+	return level, matched >= 0
+}
+
+func matchKey(key, s string) bool {
+	if s == "" || !strings.HasPrefix(s, key) {
+		return false
+	}
+	return len(s) == len(key) || strings.ContainsRune("./:", rune(s[len(key)]))
+}
+
+func (r *levelRegistry) set(key string, level Level, ttl time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.removeLocked(key)
+	o := &levelOverride{level: level}
+	if ttl > 0 {
+		o.expire = time.Now().Add(ttl)
+		o.timer = time.AfterFunc(ttl, func() {
+			r.mu.Lock()
+			defer r.mu.Unlock()
+			if r.overrides[key] == o {
+				r.removeLocked(key)
+				r.refreshLocked()
+			}
+		})
+	}
+	r.overrides[key] = o
+	r.refreshLocked()
+}
+
+func (r *levelRegistry) remove(key string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.removeLocked(key)
+	r.refreshLocked()
+}
+
+func (r *levelRegistry) removeLocked(key string) {
+	if o, ok := r.overrides[key]; ok {
+		if o.timer != nil {
+			o.timer.Stop()
+		}
+		delete(r.overrides, key)
+	}
+}
+
+func (r *levelRegistry) refreshLocked() {
+	r.min = FatalLevel
+	for _, o := range r.overrides {
+		if o.level < r.min {
+			r.min = o.level
+		}
+	}
+	atomic.StoreInt32(&r.size, int32(len(r.overrides)))
+}
+
+func (r *levelRegistry) list() []LevelOverride {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	list := make([]LevelOverride, 0, len(r.overrides))
+	for key, o := range r.overrides {
+		item := LevelOverride{Key: key, Level: o.level}
+		if !o.expire.IsZero() {
+			expire := o.expire
+			item.ExpireAt = &expire
+		}
+		list = append(list, item)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Key < list[j].Key })
+	return list
+}
+
+// enabledFor 判断 name 与 scope 对应的 logger 是否输出指定等级的日志
+// 存在匹配的模块等级时以其为准，否则使用全局等级
+func (r *levelRegistry) enabledFor(level Level, name, scope string) bool {
+	if override, ok := r.levelFor(name, scope); ok {
+		return level >= override
+	}
+	return r.global.Enabled(level)
+}
+
+// levelCore 根据 Logger Name 与 Scope 精确判断日志等级
+// 下层 core 使用 levelRegistry 作为 LevelEnabler
+type levelCore struct {
+	zapcore.Core
+	levels *levelRegistry
+	scope  string
+}
+
+func (c *levelCore) Enabled(level Level) bool {
+	return c.levels.Enabled(level)
+}
+
+func (c *levelCore) With(fields []zapcore.Field) zapcore.Core {
+	scope := c.scope
+	for _, f := range fields {
+		if f.Key == ScopeKey && f.Type == zapcore.StringType {
+			scope = f.String
+		}
+	}
+	return &levelCore{Core: c.Core.With(fields), levels: c.levels, scope: scope}
+}
+
+func (c *levelCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.levels.enabledFor(ent.Level, ent.LoggerName, c.scope) {
+		return c.Core.Check(ent, ce)
+	}
+	return ce
+}
+
+// derive 以新的 zap logger 派生 Logger，保留等级配置
+func (l *Logger) derive(logger *zap.Logger) *Logger {
+	return &Logger{
+		level:      l.level,
+		levels:     l.levels,
+		logger:     logger,
+		addCaller:  l.addCaller,
+		callerSkip: l.callerSkip,
+		close:      l.close,
+	}
+}
+
+// SetLevelFor 为 Name 或 Scope 匹配 key 的 logger 设置日志等级，
+// 作用于同一 logger 派生的所有 logger；ttl > 0 时到期自动恢复
+func (l *Logger) SetLevelFor(key string, level Level, ttl time.Duration) {
+	if l.levels != nil {
+		l.levels.set(key, level, ttl)
+	}
+}
+
+// ResetLevelFor 删除 key 对应的模块日志等级
+func (l *Logger) ResetLevelFor(key string) {
+	if l.levels != nil {
+		l.levels.remove(key)
+	}
+}
+
+// LevelOverrides 获取当前所有模块日志等级
+func (l *Logger) LevelOverrides() []LevelOverride {
+	if l.levels == nil {
+		return nil
+	}
+	return l.levels.list()
+}
+
+type levelRequest struct {
+	Key   string  `json:"key"`
+	Level *Level  `json:"level"`
+	TTL   *string `json:"ttl"`
+}
+
+type levelResponse struct {
+	Level     Level           `json:"level"`
+	Overrides []LevelOverride `json:"overrides"`
+}
+
+type levelError struct {
+	Error string `json:"error"`
+}
+
+// SetLevelHandler 设置 Web handler, 动态改变日志输出等级
+//
+// GET 获取全局等级与模块等级；
+// PUT 请求体为 {"level": "debug"} 时设置全局等级，
+// 为 {"key": "sqler", "level": "debug", "ttl": "10m"} 时设置模块等级，ttl 可选；
+// DELETE 删除查询参数 key 对应的模块等级
+func (l *Logger) SetLevelHandler(w http.ResponseWriter, r *http.Request) {
+	enc := json.NewEncoder(w)
+	w.Header().Set("Content-Type", "application/json")
+	fail := func(status int, msg string) {
+		w.WriteHeader(status)
+		_ = enc.Encode(levelError{Error: msg})
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPut, http.MethodPost:
+		var req levelRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			fail(http.StatusBadRequest, "request body must be well-formed JSON: "+err.Error())
+			return
+		}
+		if req.Level == nil {
+			fail(http.StatusBadRequest, "must specify a logging level")
+			return
+		}
+		if req.Key == "" {
+			l.SetLevel(*req.Level)
+			break
+		}
+		var ttl time.Duration
+		if req.TTL != nil {
+			var err error
+			if ttl, err = time.ParseDuration(*req.TTL); err != nil {
+				fail(http.StatusBadRequest, "invalid ttl: "+err.Error())
+				return
+			}
+		}
+		l.SetLevelFor(req.Key, *req.Level, ttl)
+	case http.MethodDelete:
+		key := r.URL.Query().Get("key")
+		if key == "" {
+			fail(http.StatusBadRequest, "must specify a key")
+			return
+		}
+		l.ResetLevelFor(key)
+	default:
+		fail(http.StatusMethodNotAllowed, "only GET, PUT and DELETE are supported")
+		return
+	}
+
+	_ = enc.Encode(levelResponse{Level: l.level.Level(), Overrides: l.LevelOverrides()})
+}
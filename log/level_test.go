@@ -0,0 +1,121 @@
+package log
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newLevelTestLogger 创建输出到临时文件的 logger，返回读取文件内容的函数
+func newLevelTestLogger(t *testing.T) (*Logger, func() string) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	config := NewDefaultConfig(JSONFormat, InfoLevel)
+	config.OutputPaths = []string{path}
+	logger, err := NewLoggerWithConfig(config)
+	require.Nil(t, err)
+	return logger, func() string {
+		data, _ := ioutil.ReadFile(path)
+		return string(data)
+	}
+}
+
+func TestLogger_SetLevelFor(t *testing.T) {
+	ass := assert.New(t)
+	root, output := newLevelTestLogger(t)
+
+	sqler := root.WithScope("sqler")
+	tx := root.WithScope("sqler.tx")
+	store := root.SetName("store").SetName("bolt")
+	other := root.WithScope("async")
+
+	root.SetLevelFor("sqler", DebugLevel, 0)
+	root.SetLevelFor("sqler.tx", ErrorLevel, 0)
+	root.SetLevelFor("store", WarnLevel, 0)
+
+	for _, c := range []struct {
+		logger *Logger
+		level  Level
+		expect bool
+	}{
+		{sqler, DebugLevel, true},
+		{tx, DebugLevel, false},
+		{tx, WarnLevel, false},
+		{tx, ErrorLevel, true},
+		{store, InfoLevel, false},
+		{store, WarnLevel, true},
+		{other, DebugLevel, false},
+		{other, InfoLevel, true},
+	} {
+		ce := c.logger.logger.Check(c.level, "msg")
+		ass.Equal(c.expect, ce != nil, "%v %v", c.logger.LevelOverrides(), c.level)
+	}
+
+	root.ResetLevelFor("sqler")
+	ass.Nil(sqler.logger.Check(DebugLevel, "msg"))
+	ass.Len(root.LevelOverrides(), 2)
+
+	other.Info("other info")
+	sqler.Info("sqler info")
+	tx.Warn("tx warn")
+	ass.Contains(output(), "other info")
+	ass.Contains(output(), "sqler info")
+	ass.NotContains(output(), "tx warn")
+}
+
+func TestLogger_SetLevelForTTL(t *testing.T) {
+	ass := assert.New(t)
+	root, output := newLevelTestLogger(t)
+	logger := root.WithScope("sqler")
+
+	logger.SetLevelFor("sqler", DebugLevel, 50*time.Millisecond)
+	logger.Debug("debug on")
+	ass.NotNil(logger.LevelOverrides()[0].ExpireAt)
+
+	time.Sleep(100 * time.Millisecond)
+	logger.Debug("debug off")
+	ass.Empty(logger.LevelOverrides())
+
+	ass.Contains(output(), "debug on")
+	ass.NotContains(output(), "debug off")
+}
+
+func TestLogger_SetLevelHandler(t *testing.T) {
+	ass := assert.New(t)
+	logger, _ := newLevelTestLogger(t)
+
+	do := func(method, target, body string) (int, levelResponse) {
+		w := httptest.NewRecorder()
+		logger.SetLevelHandler(w, httptest.NewRequest(method, target, strings.NewReader(body)))
+		var resp levelResponse
+		_ = json.Unmarshal(w.Body.Bytes(), &resp)
+		return w.Code, resp
+	}
+
+	code, resp := do(http.MethodPut, "/level", `{"key":"sqler","level":"debug","ttl":"1h"}`)
+	ass.Equal(http.StatusOK, code)
+	ass.Equal(InfoLevel, resp.Level)
+	ass.Len(resp.Overrides, 1)
+	ass.Equal("sqler", resp.Overrides[0].Key)
+	ass.Equal(DebugLevel, resp.Overrides[0].Level)
+
+	code, resp = do(http.MethodPut, "/level", `{"level":"warn"}`)
+	ass.Equal(http.StatusOK, code)
+	ass.Equal(WarnLevel, resp.Level)
+
+	code, resp = do(http.MethodDelete, "/level?key=sqler", "")
+	ass.Equal(http.StatusOK, code)
+	ass.Empty(resp.Overrides)
+
+	code, _ = do(http.MethodPut, "/level", `{"key":"sqler","level":"debug","ttl":"soon"}`)
+	ass.Equal(http.StatusBadRequest, code)
+	code, _ = do(http.MethodPatch, "/level", "")
+	ass.Equal(http.StatusMethodNotAllowed, code)
+}
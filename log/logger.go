@@ -3,7 +3,9 @@ package log
 import (
 	"fmt"
 	"io"
-	"net/http"
+	"sort"
+
+	"github.com/sanbsy/gopkg/errors"
 
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
@@ -19,18 +21,29 @@ const (
 )
 
 const (
-	IdKey    = "@logId"
-	ErrorKey = "@error"
-	ScopeKey = "@scope"
+	IdKey      = "@logId"
+	ErrorKey   = "@error"
+	ScopeKey   = "@scope"
+	TraceIdKey = "@traceId"
+	SpanIdKey  = "@spanId"
 )
 
-// CallerSkipOffset 调用栈便宜，用于输出日志位置
+// CallerSkipOffset 调用栈偏移，用于输出日志位置
+//
+// Deprecated: 日志位置根据调用栈自动计算，封装日志接口时使用 Helper 或 AddCallerSkip
 const CallerSkipOffset = 2
 
 type (
 	Logger struct {
 		level  zap.AtomicLevel
+		levels *levelRegistry
 		logger *zap.Logger
+		// addCaller 是否输出日志位置，由 caller 计算，不使用 zap.AddCaller
+		addCaller bool
+		// callerSkip 跳过封装函数后额外跳过的调用层数
+		callerSkip int
+		// close 关闭创建 logger 时打开的输出，派生的 logger 共用
+		close func()
 	}
 	Fields map[string]interface{}
 )
@@ -54,36 +67,31 @@ func (l *Logger) Sync() {
 	_ = l.logger.Sync()
 }
 
-// SetLevelHandler 设置 Web handler, 动态改变日志输出等级
-func (l *Logger) SetLevelHandler(w http.ResponseWriter, r *http.Request) {
-	l.level.ServeHTTP(w, r)
+// Close 刷新缓冲并关闭 NewLoggerWithConfig 或 LoggerConfig.Build 打开的输出，
+// 同时停止异步写入的后台协程；派生的 logger 共用同一组输出，关闭后均不可再使用
+func (l *Logger) Close() {
+	l.Sync()
+	if l.close != nil {
+		l.close()
+	}
 }
 
 // SetName 派生 Logger 并设置 Logger Name
 func (l *Logger) SetName(name string) *Logger {
-	return &Logger{
-		level:  l.level,
-		logger: l.logger.Named(name),
-	}
+	return l.derive(l.logger.Named(name))
 }
 
 // WithLogId 写入 LogID
 func (l *Logger) WithLogId(id string) *Logger {
 	logger := l.logger.With(zap.String(IdKey, id))
-	return &Logger{
-		level:  l.level,
-		logger: logger,
-	}
+	return l.derive(logger)
 }
 
 // NewNamespace 派生 Logger 并创建一个 NameSpace
 func (l *Logger) NewNamespace(name string) *Logger {
 	logger := l.logger.With(zap.Namespace(name))
 
-	return &Logger{
-		level:  l.level,
-		logger: logger,
-	}
+	return l.derive(logger)
 }
 
 // WithOutFile 派生一个Logger，将日志写入指定文件列表
@@ -97,32 +105,40 @@ func (l *Logger) WithOutFile(outputPaths []string, level Level) (*Logger, func()
 }
 
 // WithOutput 派生一个Logger，附加 writer， 收集日志信息
+// writer 为 *AsyncWriter 时以异步方式写入，Sync 时刷新缓冲区，writer 需由调用方关闭
+// 附加的 writer 仅按 level 过滤，不受 SetLevel 与 SetLevelFor 影响
 func (l *Logger) WithOutput(writer io.Writer, level Level, format string) *Logger {
-	enc := func() zapcore.Encoder {
-		if format == JSONFormat {
-			return zapcore.NewJSONEncoder(NewDefaultEncoderConfig())
-		}
-		return zapcore.NewConsoleEncoder(NewDefaultEncoderConfig())
-	}()
+	enc := newEncoder(format, NewDefaultEncoderConfig())
+	if a, ok := writer.(*AsyncWriter); ok {
+		a.setEncoder(enc.Clone())
+	}
 
 	logger := l.logger.WithOptions(zap.WrapCore(func(core zapcore.Core) zapcore.Core {
 		return zapcore.NewTee(core, zapcore.NewCore(enc, zapcore.AddSync(writer), level))
 	}))
 
-	return &Logger{
-		level:  l.level,
-		logger: logger,
-	}
+	return l.derive(logger)
 }
 
 // WithError 派生一个 Logger，并附加 Error 信息
+// 通过 errors.With 附加在错误上的字段会作为独立的 key-value 输出
 func (l *Logger) WithError(err error) *Logger {
 	if err != nil {
-		logger := l.logger.With(zap.NamedError(ErrorKey, err))
-		return &Logger{
-			level:  l.level,
-			logger: logger,
+		errFields := errors.Fields(err)
+		keys := make([]string, 0, len(errFields))
+		for key := range errFields {
+			keys = append(keys, key)
 		}
+		sort.Strings(keys)
+
+		fields := make([]zap.Field, 0, len(keys)+1)
+		fields = append(fields, zap.NamedError(ErrorKey, err))
+		for _, key := range keys {
+			fields = append(fields, zap.Any(key, errFields[key]))
+		}
+
+		logger := l.logger.With(fields...)
+		return l.derive(logger)
 	}
 	return l
 }
@@ -130,19 +146,13 @@ func (l *Logger) WithError(err error) *Logger {
 // WithScope 派生一个 Logger，并记录 Scope
 func (l *Logger) WithScope(scope string) *Logger {
 	logger := l.logger.With(zap.String(ScopeKey, scope))
-	return &Logger{
-		level:  l.level,
-		logger: logger,
-	}
+	return l.derive(logger)
 }
 
 // 设置 Key-Value 对
 func (l *Logger) WithField(key string, value interface{}) *Logger {
 	logger := l.logger.With(zap.Any(key, value))
-	return &Logger{
-		level:  l.level,
-		logger: logger,
-	}
+	return l.derive(logger)
 }
 
 // 数据 Debug 日志
@@ -241,6 +251,9 @@ func (l *Logger) log(level zapcore.Level, format string, args []interface{}, fie
 	}
 
 	if ce := l.logger.Check(level, message); ce != nil {
+		if l.addCaller {
+			ce.Entry.Caller = l.caller()
+		}
 		ce.Write(f...)
 	}
 }
@@ -251,12 +264,12 @@ func (l *Logger) Write(msg []byte) (int, error) {
 	return len(msg), nil
 }
 
-// AddCallerSkip 创建新的 logger 并且增加 CallerSkip
+// AddCallerSkip 创建新的 logger，输出日志位置时跳过封装函数后再向上跳过 skip 层调用
+// 封装函数中调用 Helper 更加可靠
 func (l *Logger) AddCallerSkip(skip int) *Logger {
-	return &Logger{
-		level:  l.level,
-		logger: l.logger.WithOptions(zap.AddCallerSkip(skip)),
-	}
+	logger := l.derive(l.logger)
+	logger.callerSkip += skip
+	return logger
 }
 
 func (l *Logger) WithSampling(opts *SamplingOption) *Logger {
@@ -268,8 +281,5 @@ func (l *Logger) WithSampling(opts *SamplingOption) *Logger {
 		return zapcore.NewSamplerWithOptions(core, opts.Tick, opts.Initial, opts.Thereafter)
 	}))
 
-	return &Logger{
-		level:  l.level,
-		logger: logger,
-	}
+	return l.derive(logger)
 }
@@ -0,0 +1,22 @@
+package log
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/sanbsy/gopkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLogger_WithError(t *testing.T) {
+	out := &bytes.Buffer{}
+	logger := NewLogger(JSONFormat, InfoLevel).WithOutput(out, InfoLevel, JSONFormat)
+
+	err := errors.Wrap(errors.With(io.EOF, "bucket", "users", "key", "k1"), "get user")
+	logger.WithError(err).Error("load failed")
+
+	ass := assert.New(t)
+	ass.Contains(out.String(), `"@error":"get user: EOF"`)
+	ass.Contains(out.String(), `"bucket":"users","key":"k1"`)
+}
@@ -0,0 +1,232 @@
+// Package logtest 提供测试中捕获与断言日志的工具
+package logtest
+
+import (
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sanbsy/gopkg/log"
+
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+type (
+	// Entry 捕获的一条日志
+	Entry struct {
+		Level      log.Level
+		Time       time.Time
+		LoggerName string
+		Message    string
+		// Caller 调用位置，格式与 @caller 一致
+		Caller string
+		// Fields 日志字段，包括 @logId、@scope 等内置字段
+		Fields map[string]interface{}
+	}
+
+	// Recorder 在内存中记录日志
+	Recorder struct {
+		logs *observer.ObservedLogs
+	}
+
+	// Entries 日志列表，支持链式过滤
+	Entries []Entry
+
+	// Option 创建 logger 的可选项
+	Option func(*options)
+
+	options struct {
+		level   log.Level
+		forward bool
+	}
+)
+
+// Level 设置 logger 的日志等级，默认 debug
+func Level(level log.Level) Option {
+	return func(o *options) {
+		o.level = level
+	}
+}
+
+// NoForward 不将日志转发到 t.Log
+func NoForward() Option {
+	return func(o *options) {
+		o.forward = false
+	}
+}
+
+// New 创建记录日志的 logger，日志同时通过 t.Log 输出
+func New(t testing.TB, opts ...Option) (*log.Logger, *Recorder) {
+	o := options{level: log.DebugLevel, forward: true}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	core, logs := observer.New(zapcore.DebugLevel)
+	if o.forward {
+		enc := zapcore.NewConsoleEncoder(log.NewDefaultEncoderConfig())
+		core = zapcore.NewTee(core, zapcore.NewCore(enc, newTestingWriter(t), zapcore.DebugLevel))
+	}
+	return log.NewLoggerWithCore(core, o.level), &Recorder{logs: logs}
+}
+
+// Replace 以记录日志的 logger 替换内置 logger，测试结束后自动恢复
+// 替换期间 log.Info、log.ErrorF 等包级函数的日志均被记录
+func Replace(t testing.TB, opts ...Option) *Recorder {
+	logger, recorder := New(t, opts...)
+	t.Cleanup(log.ReplaceDefault(logger))
+	return recorder
+}
+
+// Len 已记录的日志数量
+func (r *Recorder) Len() int {
+	return r.logs.Len()
+}
+
+// All 获取所有已记录的日志
+func (r *Recorder) All() Entries {
+	return convert(r.logs.All())
+}
+
+// Reset 清空已记录的日志
+func (r *Recorder) Reset() {
+	r.logs.TakeAll()
+}
+
+// FilterLevel 获取指定等级的日志
+func (r *Recorder) FilterLevel(level log.Level) Entries {
+	return r.All().FilterLevel(level)
+}
+
+// FilterMessage 获取信息包含 substr 的日志
+func (r *Recorder) FilterMessage(substr string) Entries {
+	return r.All().FilterMessage(substr)
+}
+
+// FilterField 获取字段 key 的值等于 value 的日志
+func (r *Recorder) FilterField(key string, value interface{}) Entries {
+	return r.All().FilterField(key, value)
+}
+
+// FilterLevel 获取指定等级的日志
+func (es Entries) FilterLevel(level log.Level) Entries {
+	return es.Filter(func(e Entry) bool { return e.Level == level })
+}
+
+// FilterMessage 获取信息包含 substr 的日志
+func (es Entries) FilterMessage(substr string) Entries {
+	return es.Filter(func(e Entry) bool { return strings.Contains(e.Message, substr) })
+}
+
+// FilterField 获取字段 key 的值等于 value 的日志
+// 数值字段按 int64、uint64、float64 记录，比较时会统一转换
+func (es Entries) FilterField(key string, value interface{}) Entries {
+	want := normalize(value)
+	return es.Filter(func(e Entry) bool {
+		v, ok := e.Fields[key]
+		return ok && normalize(v) == want
+	})
+}
+
+// FilterFieldKey 获取包含字段 key 的日志
+func (es Entries) FilterFieldKey(key string) Entries {
+	return es.Filter(func(e Entry) bool {
+		_, ok := e.Fields[key]
+		return ok
+	})
+}
+
+// Filter 获取满足 match 的日志
+func (es Entries) Filter(match func(Entry) bool) Entries {
+	var result Entries
+	for _, e := range es {
+		if match(e) {
+			result = append(result, e)
+		}
+	}
+	return result
+}
+
+// Messages 获取所有日志信息
+func (es Entries) Messages() []string {
+	msgs := make([]string, 0, len(es))
+	for _, e := range es {
+		msgs = append(msgs, e.Message)
+	}
+	return msgs
+}
+
+func convert(logged []observer.LoggedEntry) Entries {
+	entries := make(Entries, 0, len(logged))
+	for _, e := range logged {
+		entry := Entry{
+			Level:      e.Level,
+			Time:       e.Time,
+			LoggerName: e.LoggerName,
+			Message:    e.Message,
+			Fields:     e.ContextMap(),
+		}
+		if e.Caller.Defined {
+			entry.Caller = e.Caller.TrimmedPath()
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+// normalize 统一数值类型，便于比较
+func normalize(v interface{}) interface{} {
+	switch n := v.(type) {
+	case int:
+		return int64(n)
+	case int8:
+		return int64(n)
+	case int16:
+		return int64(n)
+	case int32:
+		return int64(n)
+	case uint:
+		return uint64(n)
+	case uint8:
+		return uint64(n)
+	case uint16:
+		return uint64(n)
+	case uint32:
+		return uint64(n)
+	case float32:
+		return float64(n)
+	}
+	return v
+}
+
+// testingWriter 将日志转发到 t.Log，测试结束后丢弃
+type testingWriter struct {
+	t    testing.TB
+	mu   *sync.Mutex
+	done *bool
+}
+
+func newTestingWriter(t testing.TB) testingWriter {
+	w := testingWriter{t: t, mu: &sync.Mutex{}, done: new(bool)}
+	t.Cleanup(func() {
+		w.mu.Lock()
+		*w.done = true
+		w.mu.Unlock()
+	})
+	return w
+}
+
+func (w testingWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if !*w.done {
+		w.t.Log(strings.TrimSuffix(string(p), "\n"))
+	}
+	return len(p), nil
+}
+
+func (w testingWriter) Sync() error {
+	return nil
+}
@@ -0,0 +1,52 @@
+package logtest
+
+import (
+	"context"
+	"testing"
+
+	"github.com/sanbsy/gopkg/log"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNew(t *testing.T) {
+	ass := assert.New(t)
+	logger, recorder := New(t, Level(log.InfoLevel))
+
+	logger.Debug("hidden")
+	logger.WithScope("sqler").InfoWithField("query", log.Fields{"rows": 3})
+	logger.SetName("store").ErrorF("open %s failed", "data.db")
+
+	ass.Equal(2, recorder.Len())
+	ass.Equal([]string{"query"}, recorder.FilterField(log.ScopeKey, "sqler").Messages())
+	ass.Len(recorder.FilterField("rows", 3), 1)
+	ass.Len(recorder.FilterField("rows", 4), 0)
+
+	errs := recorder.FilterLevel(log.ErrorLevel)
+	ass.Len(errs, 1)
+	ass.Equal("store", errs[0].LoggerName)
+	ass.Contains(errs[0].Caller, "logtest/logtest_test.go")
+	ass.Equal(errs, recorder.FilterMessage("data.db"))
+
+	recorder.Reset()
+	ass.Equal(0, recorder.Len())
+}
+
+func TestReplace(t *testing.T) {
+	ass := assert.New(t)
+	origin := log.Default()
+
+	t.Run("replace", func(t *testing.T) {
+		recorder := Replace(t, NoForward())
+		log.Info("package info")
+		log.ErrorWithFields("package error", log.Fields{"key": "value"})
+		log.InfoCtx(log.WithLogId(context.Background(), "id-1"), "ctx info")
+
+		ass.Equal([]string{"package info", "package error", "ctx info"}, recorder.All().Messages())
+		ass.Len(recorder.FilterField(log.IdKey, "id-1"), 1)
+		for _, e := range recorder.All() {
+			ass.Contains(e.Caller, "logtest/logtest_test.go")
+		}
+	})
+
+	ass.Equal(origin, log.Default())
+}
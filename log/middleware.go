@@ -0,0 +1,256 @@
+package log
+
+import (
+	"bufio"
+	"math/rand"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/sanbsy/gopkg/errors"
+)
+
+const (
+	// DefaultRequestIdHeader 默认的请求 ID Header
+	DefaultRequestIdHeader = "X-Request-Id"
+	// TraceparentHeader W3C trace context Header
+	TraceparentHeader = "Traceparent"
+)
+
+// MiddlewareOptions HTTP 日志中间件配置
+type MiddlewareOptions struct {
+	// 读取与回写请求 ID 的 Header，默认 X-Request-Id
+	RequestIdHeader string `json:"request_id_header" mapstructure:"request_id_header"`
+
+	// access 日志采样比例，取值 (0, 1]，默认全部记录；状态码 >= 500 的请求总是记录
+	SampleRate float64 `json:"sample_rate" mapstructure:"sample_rate"`
+
+	// 不记录 access 日志的路径前缀
+	ExcludePaths []string `json:"exclude_paths" mapstructure:"exclude_paths"`
+
+	// 可信代理的 IP 或 CIDR，仅当请求来自可信代理时才使用 X-Forwarded-For 与 X-Real-Ip，
+	// 默认不信任任何代理，remoteIp 取连接的对端地址；无法解析的条目会被忽略
+	TrustedProxies []string `json:"trusted_proxies" mapstructure:"trusted_proxies"`
+}
+
+// maxRequestIdLen 客户端传入的请求 ID 最大长度
+const maxRequestIdLen = 128
+
+func (opts *MiddlewareOptions) loadDefault() {
+	if opts.RequestIdHeader == "" {
+		opts.RequestIdHeader = DefaultRequestIdHeader
+	}
+	if opts.SampleRate <= 0 || opts.SampleRate > 1 {
+		opts.SampleRate = 1
+	}
+}
+
+func (opts *MiddlewareOptions) excluded(path string) bool {
+	for _, prefix := range opts.ExcludePaths {
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// trustedNets 解析可信代理列表
+func (opts *MiddlewareOptions) trustedNets() []*net.IPNet {
+	nets := make([]*net.IPNet, 0, len(opts.TrustedProxies))
+	for _, proxy := range opts.TrustedProxies {
+		if _, n, err := net.ParseCIDR(proxy); err == nil {
+			nets = append(nets, n)
+			continue
+		}
+		if ip := net.ParseIP(proxy); ip != nil {
+			bits := 8 * net.IPv6len
+			if ip4 := ip.To4(); ip4 != nil {
+				ip, bits = ip4, 8*net.IPv4len
+			}
+			nets = append(nets, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+		}
+	}
+	return nets
+}
+
+func (opts *MiddlewareOptions) sampled(status int) bool {
+	return status >= http.StatusInternalServerError || opts.SampleRate >= 1 || rand.Float64() < opts.SampleRate
+}
+
+// Middleware 创建 HTTP 日志中间件
+// 为每个请求注入携带请求信息与 LogID 的 logger，请求完成后输出 access 日志，
+// 并将 handler 中的 panic 恢复为 500 响应；access 与 panic 日志的位置为中间件本身
+func Middleware(logger *Logger, opts *MiddlewareOptions) func(http.Handler) http.Handler {
+	if opts == nil {
+		opts = &MiddlewareOptions{}
+	}
+	o := *opts
+	o.loadDefault()
+	trusted := o.trustedNets()
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+
+			id := r.Header.Get(o.RequestIdHeader)
+			if !validRequestId(id) {
+				id = NewLogId()
+			}
+			w.Header().Set(o.RequestIdHeader, id)
+
+			ctx := WithLogger(r.Context(), logger)
+			ctx = WithLogId(ctx, id)
+			ctx = WithTraceparent(ctx, r.Header.Get(TraceparentHeader))
+			ctx = WithFields(ctx, Fields{
+				"method":   r.Method,
+				"path":     r.URL.Path,
+				"remoteIp": remoteIP(r, trusted),
+			})
+			reqLogger := ExtractLogger(ctx)
+
+			sw := &statusWriter{ResponseWriter: w}
+			defer func() {
+				rec := recover()
+				if rec != nil {
+					if rec == http.ErrAbortHandler {
+						panic(rec)
+					}
+					reqLogger.WithError(errors.NewPanicError(rec)).Error("recover a panic")
+					// 已写入响应头时状态码无法修改，access 日志记录实际返回的状态码
+					if !sw.wroteHeader {
+						sw.WriteHeader(http.StatusInternalServerError)
+					}
+				}
+
+				status := sw.Status()
+				if o.excluded(r.URL.Path) || rec == nil && !o.sampled(status) {
+					return
+				}
+				fields := Fields{
+					"status":  status,
+					"bytes":   sw.bytes,
+					"latency": time.Since(start).String(),
+				}
+				if rec != nil {
+					fields["panic"] = true
+				}
+				if rec != nil || status >= http.StatusInternalServerError {
+					reqLogger.ErrorWithField("access", fields)
+				} else {
+					reqLogger.InfoWithField("access", fields)
+				}
+			}()
+
+			next.ServeHTTP(sw, r.WithContext(ctx))
+		})
+	}
+}
+
+// remoteIP 获取客户端 IP
+// 对端地址为可信代理时，从右向左取 X-Forwarded-For 中第一个非可信代理的地址，
+// 其次使用 X-Real-Ip；否则直接使用对端地址，避免客户端伪造
+func remoteIP(r *http.Request, trusted []*net.IPNet) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	if !isTrusted(net.ParseIP(host), trusted) {
+		return host
+	}
+
+	if xff := r.Header.Get("X-Forwarded-For"); xff != "" {
+		hops := strings.Split(xff, ",")
+		for i := len(hops) - 1; i >= 0; i-- {
+			ip := net.ParseIP(strings.TrimSpace(hops[i]))
+			if ip == nil {
+				break
+			}
+			if !isTrusted(ip, trusted) || i == 0 {
+				return ip.String()
+			}
+		}
+	}
+	if ip := net.ParseIP(strings.TrimSpace(r.Header.Get("X-Real-Ip"))); ip != nil {
+		return ip.String()
+	}
+	return host
+}
+
+func isTrusted(ip net.IP, trusted []*net.IPNet) bool {
+	if ip == nil {
+		return false
+	}
+	for _, n := range trusted {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// validRequestId 客户端传入的请求 ID 是否可用，仅允许字母、数字与 -_.:，长度不超过 maxRequestIdLen
+func validRequestId(id string) bool {
+	if id == "" || len(id) > maxRequestIdLen {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		c := id[i]
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case c == '-' || c == '_' || c == '.' || c == ':':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// statusWriter 记录响应状态码与字节数
+type statusWriter struct {
+	http.ResponseWriter
+	status      int
+	bytes       int64
+	wroteHeader bool
+}
+
+func (w *statusWriter) Status() int {
+	if w.status == 0 {
+		return http.StatusOK
+	}
+	return w.status
+}
+
+func (w *statusWriter) WriteHeader(code int) {
+	if !w.wroteHeader {
+		w.status = code
+		w.wroteHeader = true
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *statusWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	n, err := w.ResponseWriter.Write(b)
+	w.bytes += int64(n)
+	return n, err
+}
+
+func (w *statusWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (w *statusWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	if h, ok := w.ResponseWriter.(http.Hijacker); ok {
+		return h.Hijack()
+	}
+	return nil, nil, http.ErrNotSupported
+}
+
+func (w *statusWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,112 @@
+package log
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMiddleware(t *testing.T) {
+	ass := assert.New(t)
+
+	out := &bytes.Buffer{}
+	logger := NewLogger(JSONFormat, InfoLevel).WithOutput(out, InfoLevel, JSONFormat)
+
+	handler := Middleware(logger, &MiddlewareOptions{ExcludePaths: []string{"/health"}})(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/panic":
+				panic("boom")
+			case "/panic-after-write":
+				_, _ = w.Write([]byte("ok"))
+				panic("boom")
+			default:
+				InfoCtx(r.Context(), "handling")
+				_, _ = w.Write([]byte("ok"))
+			}
+		}))
+
+	req := httptest.NewRequest(http.MethodGet, "/users", nil)
+	req.Header.Set(DefaultRequestIdHeader, "req-1")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	ass.Equal("req-1", w.Header().Get(DefaultRequestIdHeader))
+	ass.Contains(out.String(), `"@message":"handling","@logId":"req-1","method":"GET","path":"/users","remoteIp":"192.0.2.1"`)
+	ass.Contains(out.String(), `"@message":"access"`)
+	ass.Contains(out.String(), `"status":200`)
+	ass.Contains(out.String(), `"bytes":2`)
+
+	out.Reset()
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/panic", nil))
+	ass.Equal(http.StatusInternalServerError, w.Code)
+	ass.NotEmpty(w.Header().Get(DefaultRequestIdHeader))
+	ass.Contains(out.String(), `"@message":"recover a panic"`)
+	ass.Contains(out.String(), `"status":500`)
+	ass.Contains(out.String(), `"panic":true`)
+
+	// 响应头已写入后 panic，access 日志保留实际的状态码
+	out.Reset()
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/panic-after-write", nil))
+	ass.Equal(http.StatusOK, w.Code)
+	access := out.String()[strings.LastIndex(out.String(), `{"@level"`):]
+	ass.Contains(access, `"@level":"error"`)
+	ass.Contains(access, `"@message":"access"`)
+	ass.Contains(access, `"status":200`)
+	ass.Contains(access, `"panic":true`)
+
+	out.Reset()
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/health", nil))
+	ass.NotContains(out.String(), `"@message":"access"`)
+}
+
+func TestMiddleware_RemoteIP(t *testing.T) {
+	ass := assert.New(t)
+	trusted := (&MiddlewareOptions{TrustedProxies: []string{"10.0.0.0/8", "192.0.2.1", "bad"}}).trustedNets()
+	ass.Len(trusted, 2)
+
+	cases := []struct {
+		remoteAddr string
+		xff        string
+		realIP     string
+		expect     string
+	}{
+		{"203.0.113.9:1234", "1.1.1.1", "", "203.0.113.9"},
+		{"192.0.2.1:1234", "", "", "192.0.2.1"},
+		{"192.0.2.1:1234", "1.1.1.1, 2.2.2.2, 10.0.0.2", "", "2.2.2.2"},
+		{"192.0.2.1:1234", "10.0.0.3, 10.0.0.2", "", "10.0.0.3"},
+		{"192.0.2.1:1234", "", "3.3.3.3", "3.3.3.3"},
+		{"192.0.2.1:1234", "", "<script>", "192.0.2.1"},
+	}
+	for _, c := range cases {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = c.remoteAddr
+		if c.xff != "" {
+			req.Header.Set("X-Forwarded-For", c.xff)
+		}
+		if c.realIP != "" {
+			req.Header.Set("X-Real-Ip", c.realIP)
+		}
+		ass.Equal(c.expect, remoteIP(req, trusted), c)
+		ass.Equal(strings.SplitN(c.remoteAddr, ":", 2)[0], remoteIP(req, nil), c)
+	}
+}
+
+func TestMiddleware_RequestId(t *testing.T) {
+	ass := assert.New(t)
+	handler := Middleware(NewLogger(JSONFormat, FatalLevel), nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	for _, id := range []string{"bad id\n", strings.Repeat("a", maxRequestIdLen+1), `"}`} {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set(DefaultRequestIdHeader, id)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		ass.NotEqual(id, w.Header().Get(DefaultRequestIdHeader))
+		ass.True(validRequestId(w.Header().Get(DefaultRequestIdHeader)))
+	}
+}
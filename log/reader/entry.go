@@ -0,0 +1,116 @@
+// Package reader 读取、过滤与输出 log 包生成的日志文件
+package reader
+
+import (
+	"bytes"
+	"encoding/json"
+	"time"
+
+	"github.com/sanbsy/gopkg/errors"
+	"github.com/sanbsy/gopkg/log"
+)
+
+var encoderConfig = log.NewDefaultEncoderConfig()
+
+// Entry 解析后的一条日志
+type Entry struct {
+	Time    time.Time
+	Level   log.Level
+	Logger  string
+	Caller  string
+	Message string
+	LogId   string
+	Scope   string
+	// Fields 内置字段以外的其他字段
+	Fields map[string]interface{}
+	// Raw 原始日志，不包含换行符
+	Raw []byte
+}
+
+// ErrInvalidEntry 无法解析的日志
+var ErrInvalidEntry = errors.New("reader: invalid log entry")
+
+// Parse 解析一行日志，支持 JSON 与 console 格式
+func Parse(line []byte) (*Entry, error) {
+	line = bytes.TrimRight(line, "\r\n")
+	if len(line) == 0 {
+		return nil, ErrInvalidEntry
+	}
+	if line[0] == '{' {
+		return parseJSON(line)
+	}
+	return parseConsole(line)
+}
+
+func parseJSON(line []byte) (*Entry, error) {
+	var fields map[string]interface{}
+	dec := json.NewDecoder(bytes.NewReader(line))
+	dec.UseNumber()
+	if err := dec.Decode(&fields); err != nil {
+		return nil, ErrInvalidEntry
+	}
+
+	e := &Entry{Raw: line, Fields: fields}
+	e.Message = e.take(encoderConfig.MessageKey)
+	e.Logger = e.take(encoderConfig.NameKey)
+	e.Caller = e.take(encoderConfig.CallerKey)
+	e.LogId = e.take(log.IdKey)
+	e.Scope = e.take(log.ScopeKey)
+	if err := e.Level.UnmarshalText([]byte(e.take(encoderConfig.LevelKey))); err != nil {
+		e.Level = log.InfoLevel
+	}
+	if ts := e.take(encoderConfig.TimeKey); ts != "" {
+		e.Time, _ = time.Parse(time.RFC3339Nano, ts)
+	}
+	return e, nil
+}
+
+// take 取出字符串字段
+func (e *Entry) take(key string) string {
+	v, ok := e.Fields[key]
+	if !ok {
+		return ""
+	}
+	s, ok := v.(string)
+	if !ok {
+		return ""
+	}
+	delete(e.Fields, key)
+	return s
+}
+
+// parseConsole 解析 console 格式的日志：时间、等级、[logger]、[caller]、信息、[JSON 字段]，以 tab 分隔
+func parseConsole(line []byte) (*Entry, error) {
+	parts := bytes.Split(line, []byte{'\t'})
+	if len(parts) < 3 {
+		return nil, ErrInvalidEntry
+	}
+	t, err := time.Parse(time.RFC3339Nano, string(parts[0]))
+	if err != nil {
+		return nil, ErrInvalidEntry
+	}
+	e := &Entry{Raw: line, Time: t}
+	if err := e.Level.UnmarshalText(parts[1]); err != nil {
+		return nil, ErrInvalidEntry
+	}
+
+	rest := parts[2:]
+	if last := rest[len(rest)-1]; len(rest) > 1 && len(last) > 0 && last[0] == '{' {
+		dec := json.NewDecoder(bytes.NewReader(last))
+		dec.UseNumber()
+		if err := dec.Decode(&e.Fields); err == nil {
+			e.LogId = e.take(log.IdKey)
+			e.Scope = e.take(log.ScopeKey)
+			rest = rest[:len(rest)-1]
+		}
+	}
+	e.Message = string(rest[len(rest)-1])
+	rest = rest[:len(rest)-1]
+	switch len(rest) {
+	case 1:
+		e.Caller = string(rest[0])
+	case 2:
+		e.Logger, e.Caller = string(rest[0]), string(rest[1])
+	}
+	return e, nil
+}
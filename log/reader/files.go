@@ -0,0 +1,96 @@
+package reader
+
+import (
+	"bufio"
+	"compress/gzip"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/sanbsy/gopkg/log"
+)
+
+const compressSuffix = ".gz"
+
+// Files 列出日志文件及 RotateWriter 生成的备份文件，按备份时间由旧到新排列，当前日志文件在最后
+// backupPath 为空时使用日志文件所在目录，suffixFormat 为空时使用 RotateWriter 的默认格式；
+// 日志文件不存在时不包含在结果中
+func Files(filename, backupPath, suffixFormat string) ([]string, error) {
+	files, err := log.BackupFiles(&log.Options{
+		FileName:     filename,
+		BackupPath:   backupPath,
+		SuffixFormat: suffixFormat,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if info, err := os.Stat(filename); err == nil && !info.IsDir() {
+		files = append(files, filename)
+	}
+	return files, nil
+}
+
+// Open 打开日志文件，.gz 文件自动解压
+func Open(path string) (io.ReadCloser, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasSuffix(path, compressSuffix) {
+		return f, nil
+	}
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+	return &gzipFile{Reader: zr, file: f}, nil
+}
+
+type gzipFile struct {
+	*gzip.Reader
+	file *os.File
+}
+
+func (f *gzipFile) Close() error {
+	_ = f.Reader.Close()
+	return f.file.Close()
+}
+
+// ReadFiles 依次读取文件中的日志，跳过无法解析的行
+// fn 返回错误时停止读取并返回该错误
+func ReadFiles(paths []string, fn func(*Entry) error) error {
+	for _, path := range paths {
+		r, err := Open(path)
+		if err != nil {
+			return err
+		}
+		err = Read(r, fn)
+		_ = r.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Read 逐行读取日志，跳过无法解析的行
+func Read(r io.Reader, fn func(*Entry) error) error {
+	br := bufio.NewReader(r)
+	for {
+		line, err := br.ReadBytes('\n')
+		if len(line) > 0 {
+			if e, perr := Parse(line); perr == nil {
+				if ferr := fn(e); ferr != nil {
+					return ferr
+				}
+			}
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
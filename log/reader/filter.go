@@ -0,0 +1,158 @@
+package reader
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sanbsy/gopkg/errors"
+	"github.com/sanbsy/gopkg/log"
+	"go.uber.org/zap/zapcore"
+)
+
+// 字段表达式的操作符
+const (
+	OpEqual     = "="
+	OpNotEqual  = "!="
+	OpContains  = "~"
+	OpGreater   = ">"
+	OpGreaterEq = ">="
+	OpLess      = "<"
+	OpLessEq    = "<="
+)
+
+// 按长度排列，保证优先匹配较长的操作符
+var operators = []string{OpNotEqual, OpGreaterEq, OpLessEq, OpEqual, OpContains, OpGreater, OpLess}
+
+type (
+	// Filter 日志过滤条件，零值匹配所有日志
+	Filter struct {
+		// 日志等级，如 log.WarnLevel 表示 warn 及以上，nil 表示不限制
+		Level zapcore.LevelEnabler
+		// 时间范围 [Since, Until)，零值表示不限制
+		Since time.Time
+		Until time.Time
+		// 日志 ID
+		LogId string
+		// 字段表达式，需全部满足
+		Exprs []Expr
+	}
+
+	// Expr 字段表达式，如 user=tom、status>=500、@message~timeout
+	// 字段名可以使用 . 访问嵌套字段，也可以使用 @message、@logger 等内置字段
+	Expr struct {
+		Key   string
+		Op    string
+		Value string
+	}
+)
+
+// ParseExpr 解析字段表达式，以第一个出现的操作符分隔字段名与值
+func ParseExpr(s string) (Expr, error) {
+	i := strings.IndexAny(s, "=!~<>")
+	if i > 0 {
+		for _, op := range operators {
+			if strings.HasPrefix(s[i:], op) {
+				return Expr{Key: strings.TrimSpace(s[:i]), Op: op, Value: strings.TrimSpace(s[i+len(op):])}, nil
+			}
+		}
+	}
+	return Expr{}, errors.Errorf("reader: invalid expression %q", s)
+}
+
+// Match 判断日志是否满足所有条件
+func (f *Filter) Match(e *Entry) bool {
+	if f.Level != nil && !f.Level.Enabled(e.Level) {
+		return false
+	}
+	if !f.Since.IsZero() && e.Time.Before(f.Since) {
+		return false
+	}
+	if !f.Until.IsZero() && !e.Time.Before(f.Until) {
+		return false
+	}
+	if f.LogId != "" && e.LogId != f.LogId {
+		return false
+	}
+	for _, expr := range f.Exprs {
+		if !expr.Match(e) {
+			return false
+		}
+	}
+	return true
+}
+
+// Match 判断日志是否满足表达式，字段不存在时仅 != 成立
+func (x Expr) Match(e *Entry) bool {
+	value, ok := e.Lookup(x.Key)
+	if !ok {
+		return x.Op == OpNotEqual
+	}
+	switch x.Op {
+	case OpEqual:
+		return value == x.Value
+	case OpNotEqual:
+		return value != x.Value
+	case OpContains:
+		return strings.Contains(value, x.Value)
+	}
+
+	a, err1 := strconv.ParseFloat(value, 64)
+	b, err2 := strconv.ParseFloat(x.Value, 64)
+	var cmp int
+	if err1 == nil && err2 == nil {
+		switch {
+		case a < b:
+			cmp = -1
+		case a > b:
+			cmp = 1
+		}
+	} else {
+		cmp = strings.Compare(value, x.Value)
+	}
+	switch x.Op {
+	case OpGreater:
+		return cmp > 0
+	case OpGreaterEq:
+		return cmp >= 0
+	case OpLess:
+		return cmp < 0
+	case OpLessEq:
+		return cmp <= 0
+	}
+	return false
+}
+
+// Lookup 以字符串形式返回字段的值，支持内置字段与 . 分隔的嵌套字段
+func (e *Entry) Lookup(key string) (string, bool) {
+	switch key {
+	case encoderConfig.MessageKey:
+		return e.Message, true
+	case encoderConfig.LevelKey:
+		return e.Level.String(), true
+	case encoderConfig.NameKey:
+		return e.Logger, e.Logger != ""
+	case encoderConfig.CallerKey:
+		return e.Caller, e.Caller != ""
+	case log.IdKey:
+		return e.LogId, e.LogId != ""
+	case log.ScopeKey:
+		return e.Scope, e.Scope != ""
+	}
+
+	var value interface{} = e.Fields
+	for _, part := range strings.Split(key, ".") {
+		m, ok := value.(map[string]interface{})
+		if !ok {
+			return "", false
+		}
+		if value, ok = m[part]; !ok {
+			return "", false
+		}
+	}
+	if s, ok := value.(string); ok {
+		return s, true
+	}
+	return fmt.Sprint(value), true
+}
@@ -0,0 +1,172 @@
+package reader
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"time"
+)
+
+// FollowOptions 持续读取配置
+type FollowOptions struct {
+	// 是否从文件开头读取，默认从文件末尾开始
+	FromStart bool
+	// 检查新数据与文件切割的间隔，默认 200ms
+	PollInterval time.Duration
+}
+
+// Follow 持续读取日志文件新写入的日志，直到 ctx 结束
+// 文件被切割（重命名后重新创建）时读完旧文件剩余内容后切换到新文件，被截断时从头读取
+func Follow(ctx context.Context, filename string, opts *FollowOptions, fn func(*Entry) error) error {
+	if opts == nil {
+		opts = &FollowOptions{}
+	}
+	interval := opts.PollInterval
+	if interval <= 0 {
+		interval = 200 * time.Millisecond
+	}
+
+	t := &tailer{filename: filename, fn: fn}
+	defer t.close()
+	fromStart := opts.FromStart
+	for {
+		if t.file == nil {
+			if err := t.open(fromStart); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+			// 切割后重新创建的文件从头读取
+			fromStart = true
+		}
+		if t.file != nil {
+			if err := t.poll(); err != nil {
+				return err
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(interval):
+		}
+	}
+}
+
+type tailer struct {
+	filename string
+	fn       func(*Entry) error
+
+	file    *os.File
+	info    os.FileInfo
+	offset  int64
+	partial []byte
+	buf     [32 * 1024]byte
+}
+
+func (t *tailer) open(fromStart bool) error {
+	f, err := os.Open(t.filename)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return err
+	}
+	t.file, t.info, t.offset = f, info, 0
+	if !fromStart {
+		if t.offset, err = f.Seek(0, io.SeekEnd); err != nil {
+			t.close()
+			return err
+		}
+	}
+	return nil
+}
+
+func (t *tailer) close() {
+	if t.file != nil {
+		_ = t.file.Close()
+		t.file = nil
+	}
+	t.partial = t.partial[:0]
+}
+
+// poll 读取已写入的数据，并检查文件是否被切割或截断
+func (t *tailer) poll() error {
+	if err := t.drain(); err != nil {
+		return err
+	}
+
+	info, err := os.Stat(t.filename)
+	switch {
+	case os.IsNotExist(err) || (err == nil && !os.SameFile(info, t.info)):
+		// 文件已被切割，读取旧文件在切割前写入的剩余内容
+		if err := t.drain(); err != nil {
+			return err
+		}
+		if err := t.flushPartial(); err != nil {
+			return err
+		}
+		t.close()
+	case err != nil:
+		return err
+	case info.Size() < t.offset:
+		t.partial = t.partial[:0]
+		if t.offset, err = t.file.Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+		return t.drain()
+	}
+	return nil
+}
+
+// drain 读取到文件末尾，处理所有完整的行
+func (t *tailer) drain() error {
+	for {
+		n, err := t.file.Read(t.buf[:])
+		if n > 0 {
+			t.offset += int64(n)
+			t.partial = append(t.partial, t.buf[:n]...)
+			if err := t.emitLines(); err != nil {
+				return err
+			}
+		}
+		if err == io.EOF || n == 0 {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func (t *tailer) emitLines() error {
+	for {
+		i := bytes.IndexByte(t.partial, '\n')
+		if i < 0 {
+			return nil
+		}
+		line := t.partial[:i]
+		if e, err := Parse(line); err == nil {
+			e.Raw = append([]byte(nil), e.Raw...)
+			if err := t.fn(e); err != nil {
+				return err
+			}
+		}
+		t.partial = t.partial[i+1:]
+	}
+}
+
+// flushPartial 处理文件末尾没有换行符的日志
+func (t *tailer) flushPartial() error {
+	if len(t.partial) == 0 {
+		return nil
+	}
+	e, err := Parse(t.partial)
+	t.partial = t.partial[:0]
+	if err != nil {
+		return nil
+	}
+	e.Raw = append([]byte(nil), e.Raw...)
+	return t.fn(e)
+}
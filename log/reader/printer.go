@@ -0,0 +1,122 @@
+package reader
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/sanbsy/gopkg/bufferpool"
+	"github.com/sanbsy/gopkg/log"
+)
+
+const (
+	colorReset   = "\x1b[0m"
+	colorRed     = "\x1b[31m"
+	colorGreen   = "\x1b[32m"
+	colorYellow  = "\x1b[33m"
+	colorBlue    = "\x1b[34m"
+	colorMagenta = "\x1b[35m"
+	colorGray    = "\x1b[90m"
+)
+
+// TimeLayout Printer 输出的时间格式
+const TimeLayout = "2006-01-02 15:04:05.000"
+
+// Printer 以易读的 console 格式输出日志：
+// 时间 等级 [logger] 信息 key=value ... (caller)
+type Printer struct {
+	w     io.Writer
+	color bool
+}
+
+// NewPrinter 创建 Printer，color 为 true 时按日志等级着色
+func NewPrinter(w io.Writer, color bool) *Printer {
+	return &Printer{w: w, color: color}
+}
+
+// Print 输出一条日志
+func (p *Printer) Print(e *Entry) error {
+	buf := bufferpool.Get()
+	defer buf.Free()
+
+	p.paint(buf, colorGray, e.Time.Format(TimeLayout))
+	buf.WriteByte(' ')
+	p.paint(buf, levelColor(e.Level), fmt.Sprintf("%-5s", strings.ToUpper(e.Level.String())))
+	if e.Logger != "" {
+		buf.WriteByte(' ')
+		p.paint(buf, colorBlue, "["+e.Logger+"]")
+	}
+	buf.WriteByte(' ')
+	buf.WriteString(e.Message)
+
+	if e.LogId != "" {
+		p.field(buf, log.IdKey, e.LogId)
+	}
+	if e.Scope != "" {
+		p.field(buf, log.ScopeKey, e.Scope)
+	}
+	keys := make([]string, 0, len(e.Fields))
+	for key := range e.Fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		p.field(buf, key, formatValue(e.Fields[key]))
+	}
+
+	if e.Caller != "" {
+		buf.WriteByte(' ')
+		p.paint(buf, colorGray, "("+e.Caller+")")
+	}
+	buf.WriteByte('\n')
+	_, err := p.w.Write(buf.Bytes())
+	return err
+}
+
+func (p *Printer) field(buf *bufferpool.Buffer, key, value string) {
+	buf.WriteByte(' ')
+	p.paint(buf, colorMagenta, key)
+	buf.WriteByte('=')
+	buf.WriteString(value)
+}
+
+func (p *Printer) paint(buf *bufferpool.Buffer, color, s string) {
+	if !p.color {
+		buf.WriteString(s)
+		return
+	}
+	buf.WriteString(color)
+	buf.WriteString(s)
+	buf.WriteString(colorReset)
+}
+
+func levelColor(level log.Level) string {
+	switch {
+	case level <= log.DebugLevel:
+		return colorMagenta
+	case level == log.InfoLevel:
+		return colorGreen
+	case level == log.WarnLevel:
+		return colorYellow
+	}
+	return colorRed
+}
+
+// formatValue 字符串包含空格等字符时加引号，对象与数组以 JSON 输出
+func formatValue(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		if v == "" || strings.ContainsAny(v, " \t\n\"=") {
+			return fmt.Sprintf("%q", v)
+		}
+		return v
+	case map[string]interface{}, []interface{}:
+		b, err := json.Marshal(v)
+		if err == nil {
+			return string(b)
+		}
+	}
+	return fmt.Sprint(v)
+}
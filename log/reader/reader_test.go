@@ -0,0 +1,206 @@
+package reader
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sanbsy/gopkg/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	ass := assert.New(t)
+
+	var buf bytes.Buffer
+	logger := log.NewLogger(log.JSONFormat, log.InfoLevel).WithOutput(&buf, log.DebugLevel, log.JSONFormat)
+	logger.SetName("api").WithLogId("id-1").WithScope("db").
+		WithField("user", map[string]interface{}{"name": "tom", "age": 18}).
+		WithField("status", 502).Warn("slow query")
+
+	e, err := Parse(buf.Bytes())
+	require.Nil(t, err)
+	ass.Equal(log.WarnLevel, e.Level)
+	ass.Equal("api", e.Logger)
+	ass.Equal("slow query", e.Message)
+	ass.Equal("id-1", e.LogId)
+	ass.Equal("db", e.Scope)
+	ass.Contains(e.Caller, "reader_test.go")
+	ass.WithinDuration(time.Now(), e.Time, time.Minute)
+	ass.NotContains(e.Fields, "@message")
+
+	v, ok := e.Lookup("user.name")
+	ass.True(ok)
+	ass.Equal("tom", v)
+	v, _ = e.Lookup("status")
+	ass.Equal("502", v)
+
+	buf.Reset()
+	logger = log.NewLogger(log.JSONFormat, log.InfoLevel).WithOutput(&buf, log.DebugLevel, log.TextFormat)
+	logger.WithField("n", 1).Error("failed")
+	e, err = Parse(buf.Bytes())
+	require.Nil(t, err)
+	ass.Equal(log.ErrorLevel, e.Level)
+	ass.Equal("failed", e.Message)
+	ass.Contains(e.Caller, "reader_test.go")
+
+	e, err = Parse([]byte("2020-05-01T10:00:00.000+08:00\tERROR\tapi\tmain.go:10\tfailed\t{\"@logId\":\"x\",\"n\":1}"))
+	require.Nil(t, err)
+	ass.Equal(log.ErrorLevel, e.Level)
+	ass.Equal("api", e.Logger)
+	ass.Equal("main.go:10", e.Caller)
+	ass.Equal("failed", e.Message)
+	ass.Equal("x", e.LogId)
+	v, _ = e.Lookup("n")
+	ass.Equal("1", v)
+
+	_, err = Parse([]byte("not a log"))
+	ass.Equal(ErrInvalidEntry, err)
+}
+
+func TestFilter(t *testing.T) {
+	ass := assert.New(t)
+
+	e, err := Parse([]byte(`{"@timestamp":"2020-05-01T10:00:00.000+08:00","@level":"warn","@message":"request timeout","@logId":"a","status":502,"path":"/api"}`))
+	require.Nil(t, err)
+
+	cases := []struct {
+		expr  string
+		match bool
+	}{
+		{"status=502", true},
+		{"status!=502", false},
+		{"status>=500", true},
+		{"status<500", false},
+		{"path~api", true},
+		{"@message~timeout", true},
+		{"user!=tom", true},
+		{"user=tom", false},
+	}
+	for _, c := range cases {
+		x, err := ParseExpr(c.expr)
+		require.Nil(t, err, c.expr)
+		ass.Equal(c.match, x.Match(e), c.expr)
+	}
+	_, err = ParseExpr("status")
+	ass.NotNil(err)
+
+	at := e.Time
+	ass.True((&Filter{}).Match(e))
+	ass.True((&Filter{Level: log.WarnLevel, Since: at, Until: at.Add(time.Second), LogId: "a"}).Match(e))
+	ass.False((&Filter{Level: log.ErrorLevel}).Match(e))
+	ass.False((&Filter{Until: at}).Match(e))
+	ass.False((&Filter{LogId: "b"}).Match(e))
+}
+
+func TestFiles(t *testing.T) {
+	ass := assert.New(t)
+	dir := t.TempDir()
+	now := time.Now()
+
+	write := func(name, data string, age time.Duration, compress bool) {
+		var content bytes.Buffer
+		if compress {
+			zw := gzip.NewWriter(&content)
+			_, _ = zw.Write([]byte(data))
+			_ = zw.Close()
+		} else {
+			content.WriteString(data)
+		}
+		path := filepath.Join(dir, name)
+		require.Nil(t, ioutil.WriteFile(path, content.Bytes(), 0644))
+		require.Nil(t, os.Chtimes(path, now.Add(-age), now.Add(-age)))
+	}
+	line := func(msg string) string {
+		return `{"@level":"info","@message":"` + msg + `"}` + "\n"
+	}
+	write("app-20200501T100000.log.gz", line("1"), 3*time.Hour, true)
+	write("app-20200501T110000.log", line("2"), time.Hour, false)
+	write("app-20200501T110000-1.log", line("3"), time.Hour, false)
+	write("app.log", line("4"), 0, false)
+	write("other.log", line("x"), 0, false)
+	write("app-worker.log", line("x"), 0, false)
+
+	files, err := Files(filepath.Join(dir, "app.log"), "", "")
+	require.Nil(t, err)
+	ass.Equal([]string{
+		filepath.Join(dir, "app-20200501T100000.log.gz"),
+		filepath.Join(dir, "app-20200501T110000.log"),
+		filepath.Join(dir, "app-20200501T110000-1.log"),
+		filepath.Join(dir, "app.log"),
+	}, files)
+
+	var messages []string
+	ass.Nil(ReadFiles(files, func(e *Entry) error {
+		messages = append(messages, e.Message)
+		return nil
+	}))
+	ass.Equal([]string{"1", "2", "3", "4"}, messages)
+}
+
+func TestFollow(t *testing.T) {
+	ass := assert.New(t)
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "app.log")
+	w := log.NewWriter(&log.Options{FileName: filename})
+	defer w.Close()
+
+	line := func(msg string) []byte {
+		return []byte(`{"@level":"info","@message":"` + msg + `"}` + "\n")
+	}
+	_, err := w.Write(line("old"))
+	require.Nil(t, err)
+
+	var (
+		mu       sync.Mutex
+		messages []string
+	)
+	got := func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), messages...)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- Follow(ctx, filename, &FollowOptions{PollInterval: 10 * time.Millisecond}, func(e *Entry) error {
+			mu.Lock()
+			messages = append(messages, e.Message)
+			mu.Unlock()
+			return nil
+		})
+	}()
+	time.Sleep(50 * time.Millisecond)
+
+	_, _ = w.Write(line("1"))
+	_, _ = w.Write([]byte(`{"@level":"info","@message":"2"}`))
+	ass.Nil(w.Rotate())
+	_, _ = w.Write(line("3"))
+
+	ass.Eventually(func() bool { return len(got()) == 3 }, time.Second, 10*time.Millisecond)
+	ass.Equal([]string{"1", "2", "3"}, got())
+
+	cancel()
+	ass.Nil(<-done)
+}
+
+func TestPrinter(t *testing.T) {
+	e, err := Parse([]byte(`{"@timestamp":"2020-05-01T10:00:00.000+08:00","@level":"warn","@logger":"api","@caller":"main.go:10","@message":"slow","@logId":"a","cost":1.5,"sql":"select 1"}`))
+	require.Nil(t, err)
+
+	var buf bytes.Buffer
+	assert.Nil(t, NewPrinter(&buf, false).Print(e))
+	assert.Equal(t, `2020-05-01 10:00:00.000 WARN  [api] slow @logId=a cost=1.5 sql="select 1" (main.go:10)`+"\n", buf.String())
+
+	buf.Reset()
+	assert.Nil(t, NewPrinter(&buf, true).Print(e))
+	assert.Contains(t, buf.String(), colorYellow+"WARN ")
+}
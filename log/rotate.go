@@ -1,12 +1,13 @@
 package log
 
 import (
-	"errors"
 	"fmt"
 	"os"
+	"os/signal"
 	"path/filepath"
 	"strings"
 	"sync"
+	"syscall"
 	"time"
 
 	"github.com/sanbsy/gopkg/bufferpool"
@@ -33,28 +34,76 @@ type (
 		timeSuffix string
 		index      int
 
+		// 按时间切割的周期，以及下一次切割的时间
+		interval   string
+		nextRotate time.Time
+
+		// 备份文件保留数量与天数
+		maxBackups int
+		maxAge     int
+
+		// 是否压缩备份文件
+		compress bool
+
+		// 备份文件名是否使用 UTC 时间
+		utc bool
+
 		// 日志文件句柄
 		file *os.File
 
+		// 上一次检查日志文件是否被外部移动的时间
+		lastCheck time.Time
+
+		// 后台清理、压缩备份文件
+		millCh   chan struct{}
+		millDone chan struct{}
+
 		mu sync.Mutex
 	}
 
 	// Options 日志自动备份配置
 	Options struct {
 		// 日志文件名称
-		FileName string `json:"file_name" mapstructure:"file_name"`
+		FileName string `json:"file_name" yaml:"file_name" mapstructure:"file_name"`
 
 		// 日志备份路径
-		BackupPath string `json:"backup_path" mapstructure:"backup_path"`
+		BackupPath string `json:"backup_path" yaml:"backup_path" mapstructure:"backup_path"`
 
 		// 日志文件最大字节数，单位为MB
-		MaxSize int `json:"max_size" mapstructure:"max_size"`
+		MaxSize int `json:"max_size" yaml:"max_size" mapstructure:"max_size"`
 
 		// 备份文件，日期后缀格式
-		SuffixFormat string `json:"suffix_format" mapstructure:"suffix_format"`
+		SuffixFormat string `json:"suffix_format" yaml:"suffix_format" mapstructure:"suffix_format"`
+
+		// 按时间切割日志，可选 hourly、daily，为空时仅按大小切割
+		RotateInterval string `json:"rotate_interval" yaml:"rotate_interval" mapstructure:"rotate_interval"`
+
+		// 最多保留的备份文件数量，0 表示不限制
+		MaxBackups int `json:"max_backups" yaml:"max_backups" mapstructure:"max_backups"`
+
+		// 备份文件最长保留天数，0 表示不限制
+		MaxAge int `json:"max_age" yaml:"max_age" mapstructure:"max_age"`
+
+		// 是否以 gzip 压缩备份文件
+		Compress bool `json:"compress" yaml:"compress" mapstructure:"compress"`
+
+		// 切割时间与备份文件名是否使用 UTC 时间，默认使用本地时间
+		UTC bool `json:"utc" yaml:"utc" mapstructure:"utc"`
 	}
 )
 
+// 按时间切割日志的周期
+const (
+	RotateHourly = "hourly"
+	RotateDaily  = "daily"
+)
+
+// fileCheckInterval 检查日志文件是否被外部删除或移动的间隔
+const fileCheckInterval = time.Second
+
+// currentTime 获取当前时间，便于测试替换
+var currentTime = time.Now
+
 func (opts *Options) loadDefault() {
 	if opts.MaxSize <= 0 {
 		opts.MaxSize = 10
@@ -83,24 +132,29 @@ func NewWriter(opts *Options) *RotateWriter {
 		backupPath:   opts.BackupPath,
 		maxSize:      int64(opts.MaxSize * 1024 * 1024),
 		suffixFormat: opts.SuffixFormat,
+		interval:     opts.RotateInterval,
+		maxBackups:   opts.MaxBackups,
+		maxAge:       opts.MaxAge,
+		compress:     opts.Compress,
+		utc:          opts.UTC,
 	}
 }
 
 // Write 实现 Writer 接口
+// 单条数据超过 maxSize 时不会被拆分，而是完整写入一个新的日志文件
 func (r *RotateWriter) Write(data []byte) (n int, err error) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 	dataLen := int64(len(data))
-	if dataLen > r.maxSize {
-		return 0, errors.New("write length exceeds maximum file size")
-	}
 	if r.file == nil {
 		if err = r.open(); err != nil {
 			return 0, err
 		}
+	} else if err = r.checkFile(); err != nil {
+		return 0, err
 	}
 
-	if r.curSize+dataLen > r.maxSize {
+	if r.curSize > 0 && r.curSize+dataLen > r.maxSize || r.shouldRotateByTime() {
 		if err := r.rotate(); err != nil {
 			return 0, err
 		}
@@ -112,9 +166,86 @@ func (r *RotateWriter) Write(data []byte) (n int, err error) {
 	return n, err
 }
 
+// Rotate 立即切割日志文件
+func (r *RotateWriter) Rotate() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.file == nil {
+		if err := r.open(); err != nil {
+			return err
+		}
+	}
+	return r.rotate()
+}
+
+// Reopen 重新打开日志文件，用于日志文件被外部工具（如 logrotate）移动之后
+func (r *RotateWriter) Reopen() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := r.close(); err != nil {
+		return err
+	}
+	return r.open()
+}
+
+// ReopenOnSignal 收到指定信号时重新打开日志文件，未指定信号时使用 SIGHUP
+// 返回的函数用于停止监听
+func (r *RotateWriter) ReopenOnSignal(sigs ...os.Signal) (stop func()) {
+	if len(sigs) == 0 {
+		sigs = []os.Signal{syscall.SIGHUP}
+	}
+	ch := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	signal.Notify(ch, sigs...)
+
+	go func() {
+		for {
+			select {
+			case <-ch:
+				_ = r.Reopen()
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			signal.Stop(ch)
+			close(done)
+		})
+	}
+}
+
+// checkFile 定期检查日志文件是否被外部删除或移动，是则重新打开
+func (r *RotateWriter) checkFile() error {
+	now := currentTime()
+	if now.Sub(r.lastCheck) < fileCheckInterval {
+		return nil
+	}
+	r.lastCheck = now
+
+	info, err := os.Stat(r.filename)
+	if err == nil {
+		if current, e := r.file.Stat(); e == nil && os.SameFile(info, current) {
+			return nil
+		}
+	} else if !os.IsNotExist(err) {
+		return nil
+	}
+
+	if err := r.close(); err != nil {
+		return err
+	}
+	return r.open()
+}
+
+// Close 关闭日志文件，并等待后台的清理、压缩任务完成
 func (r *RotateWriter) Close() error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
+	r.stopMill()
 	return r.close()
 }
 
@@ -138,15 +269,59 @@ func (r *RotateWriter) open() error {
 	}
 
 	file, err := os.OpenFile(r.filename, os.O_APPEND|os.O_WRONLY|os.O_CREATE, 0644)
+	if err != nil {
+		return fmt.Errorf("can't open logfile: %s", err)
+	}
 
 	r.curSize = 0
+	r.lastCheck = currentTime()
 	r.file = file
+	r.nextRotate = r.nextBoundary(r.now())
 	if info != nil {
 		r.curSize = info.Size()
+		r.nextRotate = r.nextBoundary(info.ModTime())
 	}
 	return nil
 }
 
+func (r *RotateWriter) now() time.Time {
+	if r.utc {
+		return currentTime().UTC()
+	}
+	return currentTime().Local()
+}
+
+// nextBoundary 获取 t 所在周期的结束时间，未开启按时间切割时返回零值
+func (r *RotateWriter) nextBoundary(t time.Time) time.Time {
+	if r.utc {
+		t = t.UTC()
+	} else {
+		t = t.Local()
+	}
+	switch r.interval {
+	case RotateHourly:
+		return time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+	case RotateDaily:
+		return time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+	}
+	return time.Time{}
+}
+
+// periodStart 获取 t 所在周期的开始时间
+func (r *RotateWriter) periodStart(t time.Time) time.Time {
+	switch r.interval {
+	case RotateHourly:
+		return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), 0, 0, 0, t.Location())
+	case RotateDaily:
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	}
+	return t
+}
+
+func (r *RotateWriter) shouldRotateByTime() bool {
+	return !r.nextRotate.IsZero() && !r.now().Before(r.nextRotate)
+}
+
 func (r *RotateWriter) rotate() error {
 	if err := r.close(); err != nil {
 		return err
@@ -156,7 +331,8 @@ func (r *RotateWriter) rotate() error {
 		return fmt.Errorf("can't make directories for backup: %s", err)
 	}
 
-	if err := os.Rename(r.filename, r.backupName()); err != nil {
+	// 日志文件已被外部删除时，直接创建新文件
+	if err := os.Rename(r.filename, r.backupName()); err != nil && !os.IsNotExist(err) {
 		return fmt.Errorf("can't rename log file: %s", err)
 	}
 
@@ -166,29 +342,51 @@ func (r *RotateWriter) rotate() error {
 	}
 	r.file = f
 	r.curSize = 0
+	r.nextRotate = r.nextBoundary(r.now())
+	r.mill()
 	return nil
 }
 
+// backupName 获取备份文件名，跳过磁盘上已存在的文件（含压缩后的 .gz），
+// 避免重启后同一周期内的切割覆盖已有备份
 func (r *RotateWriter) backupName() string {
-	buf := bufferpool.Get()
-	defer buf.Free()
-	ext := filepath.Ext(r.filename)
-	buf.WriteString(strings.TrimSuffix(filepath.Base(r.filename), ext))
-
-	timeSuffix := time.Now().Format(r.suffixFormat)
+	// 按时间切割时以文件内容所在周期的开始时间命名
+	t := r.now()
+	if !r.nextRotate.IsZero() {
+		t = r.periodStart(r.nextRotate.Add(-time.Nanosecond))
+	}
+	timeSuffix := t.Format(r.suffixFormat)
 	if timeSuffix != r.timeSuffix {
 		r.timeSuffix = timeSuffix
 		r.index = 0
 	}
 
+	for {
+		name := r.indexedName(r.index)
+		r.index++
+		if !fileExists(name) && !fileExists(name+compressSuffix) {
+			return name
+		}
+	}
+}
+
+// indexedName 以当前时间后缀与 index 拼接备份文件名
+func (r *RotateWriter) indexedName(index int) string {
+	buf := bufferpool.Get()
+	defer buf.Free()
+	ext := filepath.Ext(r.filename)
+	buf.WriteString(strings.TrimSuffix(filepath.Base(r.filename), ext))
 	buf.WriteByte('-')
 	buf.WriteString(r.timeSuffix)
-	if r.index > 0 {
+	if index > 0 {
 		buf.WriteByte('-')
-		buf.WriteInt(r.index)
+		buf.WriteInt(index)
 	}
-	r.index++
-
 	buf.WriteString(ext)
 	return filepath.Join(r.backupPath, buf.String())
 }
+
+func fileExists(path string) bool {
+	_, err := os.Lstat(path)
+	return err == nil
+}
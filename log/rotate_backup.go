@@ -0,0 +1,221 @@
+package log
+
+import (
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const compressSuffix = ".gz"
+
+type backupFile struct {
+	path    string
+	modTime time.Time
+	// timestamp 与 index 从文件名中解析，用于排序
+	timestamp time.Time
+	index     int
+}
+
+// mill 触发后台清理、压缩备份文件，调用方需持有锁
+func (r *RotateWriter) mill() {
+	if r.maxBackups <= 0 && r.maxAge <= 0 && !r.compress {
+		return
+	}
+	if r.millCh == nil {
+		r.millCh = make(chan struct{}, 1)
+		r.millDone = make(chan struct{})
+		go r.millRun(r.millCh, r.millDone)
+	}
+	select {
+	case r.millCh <- struct{}{}:
+	default:
+	}
+}
+
+// stopMill 停止后台任务并等待其完成，调用方需持有锁
+func (r *RotateWriter) stopMill() {
+	if r.millCh == nil {
+		return
+	}
+	close(r.millCh)
+	<-r.millDone
+	r.millCh = nil
+	r.millDone = nil
+}
+
+func (r *RotateWriter) millRun(ch <-chan struct{}, done chan<- struct{}) {
+	defer close(done)
+	for range ch {
+		_ = r.millRunOnce()
+	}
+}
+
+// millRunOnce 压缩未压缩的备份文件，并删除超出数量或过期的备份文件
+func (r *RotateWriter) millRunOnce() error {
+	files, err := r.backupFiles()
+	if err != nil {
+		return err
+	}
+
+	var remove []backupFile
+	if r.maxBackups > 0 && len(files) > r.maxBackups {
+		remove = append(remove, files[r.maxBackups:]...)
+		files = files[:r.maxBackups]
+	}
+	if r.maxAge > 0 {
+		cutoff := currentTime().Add(-time.Duration(r.maxAge) * 24 * time.Hour)
+		kept := files[:0]
+		for _, f := range files {
+			if f.modTime.Before(cutoff) {
+				remove = append(remove, f)
+			} else {
+				kept = append(kept, f)
+			}
+		}
+		files = kept
+	}
+
+	for _, f := range remove {
+		if e := os.Remove(f.path); e != nil && !os.IsNotExist(e) && err == nil {
+			err = e
+		}
+	}
+
+	if r.compress {
+		for _, f := range files {
+			if strings.HasSuffix(f.path, compressSuffix) {
+				continue
+			}
+			if e := compressFile(f.path, f.modTime); e != nil && err == nil {
+				err = e
+			}
+		}
+	}
+	return err
+}
+
+// backupFiles 列出所有备份文件，按备份时间由新到旧排列
+func (r *RotateWriter) backupFiles() ([]backupFile, error) {
+	return listBackups(r.filename, r.backupPath, r.suffixFormat)
+}
+
+// BackupFiles 列出 RotateWriter 按 opts 生成的备份文件，按备份时间由旧到新排列
+// 只包含时间后缀能以 SuffixFormat 解析的文件，同目录下的其他文件不会被包含
+func BackupFiles(opts *Options) ([]string, error) {
+	o := Options{}
+	if opts != nil {
+		o = *opts
+	}
+	o.loadDefault()
+	files, err := listBackups(o.FileName, o.BackupPath, o.SuffixFormat)
+	if err != nil {
+		return nil, err
+	}
+	paths := make([]string, len(files))
+	for i, f := range files {
+		paths[len(files)-1-i] = f.path
+	}
+	return paths, nil
+}
+
+// listBackups 列出 backupPath 中 filename 的备份文件，按备份时间由新到旧排列
+func listBackups(filename, backupPath, suffixFormat string) ([]backupFile, error) {
+	entries, err := ioutil.ReadDir(backupPath)
+	if err != nil {
+		return nil, err
+	}
+
+	ext := filepath.Ext(filename)
+	prefix := strings.TrimSuffix(filepath.Base(filename), ext) + "-"
+	current, _ := filepath.Abs(filename)
+
+	var files []backupFile
+	for _, e := range entries {
+		if e.IsDir() {
+			continue
+		}
+		timestamp, index, ok := parseBackupName(e.Name(), prefix, ext, suffixFormat)
+		if !ok {
+			continue
+		}
+		path := filepath.Join(backupPath, e.Name())
+		if abs, _ := filepath.Abs(path); abs == current {
+			continue
+		}
+		files = append(files, backupFile{path: path, modTime: e.ModTime(), timestamp: timestamp, index: index})
+	}
+
+	sort.Slice(files, func(i, j int) bool {
+		if files[i].timestamp.Equal(files[j].timestamp) {
+			return files[i].index > files[j].index
+		}
+		return files[i].timestamp.After(files[j].timestamp)
+	})
+	return files, nil
+}
+
+// parseBackupName 解析备份文件名 <prefix><time>[-<index>]<ext>[.gz]，返回时间后缀与序号
+func parseBackupName(name, prefix, ext, suffixFormat string) (time.Time, int, bool) {
+	middle := strings.TrimSuffix(name, compressSuffix)
+	if !strings.HasPrefix(middle, prefix) || !strings.HasSuffix(middle, ext) || len(middle) < len(prefix)+len(ext) {
+		return time.Time{}, 0, false
+	}
+	middle = middle[len(prefix) : len(middle)-len(ext)]
+
+	if t, err := time.Parse(suffixFormat, middle); err == nil {
+		return t, 0, true
+	}
+	if i := strings.LastIndexByte(middle, '-'); i > 0 {
+		if index, err := strconv.Atoi(middle[i+1:]); err == nil && index > 0 {
+			if t, err := time.Parse(suffixFormat, middle[:i]); err == nil {
+				return t, index, true
+			}
+		}
+	}
+	return time.Time{}, 0, false
+}
+
+// compressFile 将文件压缩为 .gz 并删除原文件，保留原文件的修改时间
+func compressFile(path string, modTime time.Time) (err error) {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	tmp := path + compressSuffix + ".tmp"
+	dst, err := os.OpenFile(tmp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = os.Remove(tmp)
+		}
+	}()
+
+	gz := gzip.NewWriter(dst)
+	if _, err = io.Copy(gz, src); err != nil {
+		_ = dst.Close()
+		return err
+	}
+	if err = gz.Close(); err != nil {
+		_ = dst.Close()
+		return err
+	}
+	if err = dst.Close(); err != nil {
+		return err
+	}
+
+	if err = os.Rename(tmp, path+compressSuffix); err != nil {
+		return err
+	}
+	_ = os.Chtimes(path+compressSuffix, modTime, modTime)
+	return os.Remove(path)
+}
@@ -0,0 +1,241 @@
+package log
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setCurrentTime(t *testing.T, now *time.Time) {
+	origin := currentTime
+	currentTime = func() time.Time { return *now }
+	t.Cleanup(func() { currentTime = origin })
+}
+
+func readDir(t *testing.T, dir string) []string {
+	entries, err := ioutil.ReadDir(dir)
+	require.Nil(t, err)
+	names := make([]string, 0, len(entries))
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	return names
+}
+
+func TestRotateWriter_RotateInterval(t *testing.T) {
+	ass := assert.New(t)
+	dir := t.TempDir()
+	now := time.Date(2020, 5, 1, 10, 0, 0, 0, time.UTC)
+	setCurrentTime(t, &now)
+
+	w := NewWriter(&Options{
+		FileName:       filepath.Join(dir, "app.log"),
+		RotateInterval: RotateDaily,
+		UTC:            true,
+	})
+
+	_, err := w.Write([]byte("day1\n"))
+	ass.Nil(err)
+	now = now.Add(10 * time.Hour)
+	_, err = w.Write([]byte("day1 late\n"))
+	ass.Nil(err)
+	now = now.Add(4 * time.Hour)
+	_, err = w.Write([]byte("day2\n"))
+	ass.Nil(err)
+	ass.Nil(w.Close())
+
+	ass.ElementsMatch([]string{"app.log", "app-20200501T000000.log"}, readDir(t, dir))
+
+	data, err := ioutil.ReadFile(filepath.Join(dir, "app-20200501T000000.log"))
+	ass.Nil(err)
+	ass.Equal("day1\nday1 late\n", string(data))
+
+	data, err = ioutil.ReadFile(filepath.Join(dir, "app.log"))
+	ass.Nil(err)
+	ass.Equal("day2\n", string(data))
+}
+
+func TestRotateWriter_Retention(t *testing.T) {
+	ass := assert.New(t)
+	dir := t.TempDir()
+	backup := filepath.Join(dir, "backup")
+	now := time.Now().Truncate(time.Hour)
+	setCurrentTime(t, &now)
+
+	w := NewWriter(&Options{
+		FileName:       filepath.Join(dir, "app.log"),
+		BackupPath:     backup,
+		RotateInterval: RotateHourly,
+		MaxBackups:     2,
+		Compress:       true,
+	})
+
+	for i := 0; i < 5; i++ {
+		_, err := w.Write([]byte(strings.Repeat("x", 100) + "\n"))
+		ass.Nil(err)
+		now = now.Add(time.Hour)
+		// 保证备份文件的修改时间有序
+		time.Sleep(10 * time.Millisecond)
+	}
+	ass.Nil(w.Close())
+
+	files := readDir(t, backup)
+	ass.Len(files, 2)
+	for _, name := range files {
+		ass.True(strings.HasSuffix(name, ".log.gz"), name)
+	}
+}
+
+func TestRotateWriter_Oversize(t *testing.T) {
+	ass := assert.New(t)
+	dir := t.TempDir()
+
+	w := NewWriter(&Options{FileName: filepath.Join(dir, "app.log"), MaxSize: 1})
+	defer w.Close()
+
+	_, err := w.Write([]byte("small\n"))
+	ass.Nil(err)
+
+	big := strings.Repeat("x", 2*1024*1024) + "\n"
+	n, err := w.Write([]byte(big))
+	ass.Nil(err)
+	ass.Equal(len(big), n)
+
+	_, err = w.Write([]byte("after\n"))
+	ass.Nil(err)
+
+	ass.Len(readDir(t, dir), 3)
+	data, err := ioutil.ReadFile(filepath.Join(dir, "app.log"))
+	ass.Nil(err)
+	ass.Equal("after\n", string(data))
+}
+
+func TestRotateWriter_RestartSamePeriod(t *testing.T) {
+	ass := assert.New(t)
+	dir := t.TempDir()
+	now := time.Date(2020, 5, 1, 10, 0, 0, 0, time.UTC)
+	setCurrentTime(t, &now)
+	opts := &Options{
+		FileName:       filepath.Join(dir, "app.log"),
+		RotateInterval: RotateDaily,
+		UTC:            true,
+	}
+
+	w := NewWriter(opts)
+	_, err := w.Write([]byte("first\n"))
+	ass.Nil(err)
+	ass.Nil(w.Rotate())
+	ass.Nil(w.Close())
+
+	// 文件修改时间与模拟时钟保持一致
+	require.Nil(t, os.Chtimes(opts.FileName, now, now))
+	// 已压缩的备份同样不能被覆盖
+	gz := filepath.Join(dir, "app-20200501T000000-1.log.gz")
+	require.Nil(t, ioutil.WriteFile(gz, []byte("compressed\n"), 0644))
+
+	// 同一周期内重启后 index 从 0 开始，需跳过已存在的备份
+	now = now.Add(time.Hour)
+	w = NewWriter(opts)
+	_, err = w.Write([]byte("second\n"))
+	ass.Nil(err)
+	ass.Nil(w.Rotate())
+	ass.Nil(w.Close())
+
+	for name, content := range map[string]string{
+		"app-20200501T000000.log":      "first\n",
+		"app-20200501T000000-1.log.gz": "compressed\n",
+		"app-20200501T000000-2.log":    "second\n",
+	} {
+		data, err := ioutil.ReadFile(filepath.Join(dir, name))
+		ass.Nil(err)
+		ass.Equal(content, string(data))
+	}
+}
+
+func TestRotateWriter_OpenError(t *testing.T) {
+	dir := t.TempDir()
+	w := NewWriter(&Options{FileName: dir})
+
+	_, err := w.Write([]byte("hello\n"))
+	assert.NotNil(t, err)
+}
+
+func TestRotateWriter_Reopen(t *testing.T) {
+	ass := assert.New(t)
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "app.log")
+	now := time.Now()
+	setCurrentTime(t, &now)
+
+	w := NewWriter(&Options{FileName: filename})
+	defer w.Close()
+
+	_, err := w.Write([]byte("first\n"))
+	ass.Nil(err)
+
+	// 模拟 logrotate 移动日志文件后发送信号
+	ass.Nil(os.Rename(filename, filename+".1"))
+	ass.Nil(w.Reopen())
+	_, err = w.Write([]byte("second\n"))
+	ass.Nil(err)
+
+	// 日志文件被外部删除，超过检查间隔后自动重新创建
+	ass.Nil(os.Remove(filename))
+	now = now.Add(2 * fileCheckInterval)
+	_, err = w.Write([]byte("third\n"))
+	ass.Nil(err)
+
+	data, err := ioutil.ReadFile(filename)
+	ass.Nil(err)
+	ass.Equal("third\n", string(data))
+
+	data, err = ioutil.ReadFile(filename + ".1")
+	ass.Nil(err)
+	ass.Equal("first\n", string(data))
+
+	ass.Nil(w.Rotate())
+	ass.Len(readDir(t, dir), 3)
+}
+
+func TestRotateWriter_BackupFiles(t *testing.T) {
+	ass := assert.New(t)
+	dir := t.TempDir()
+	now := time.Now()
+	for i, name := range []string{
+		"app-20200501T100000-1.log",
+		"app-20200501T100000.log.gz",
+		"app-20200502T100000.log",
+		"app-worker.log",
+		"app-20200501.log",
+		"app.log",
+	} {
+		path := filepath.Join(dir, name)
+		require.Nil(t, ioutil.WriteFile(path, []byte(name+"\n"), 0644))
+		// 修改时间与备份时间顺序相反
+		modTime := now.Add(-time.Duration(i) * time.Hour)
+		require.Nil(t, os.Chtimes(path, modTime, modTime))
+	}
+
+	files, err := BackupFiles(&Options{FileName: filepath.Join(dir, "app.log")})
+	ass.Nil(err)
+	ass.Equal([]string{
+		filepath.Join(dir, "app-20200501T100000.log.gz"),
+		filepath.Join(dir, "app-20200501T100000-1.log"),
+		filepath.Join(dir, "app-20200502T100000.log"),
+	}, files)
+
+	// 清理备份文件时不影响同目录下的其他文件
+	w := NewWriter(&Options{FileName: filepath.Join(dir, "app.log"), MaxBackups: 1, Compress: true})
+	ass.Nil(w.Rotate())
+	ass.Nil(w.Close())
+	names := readDir(t, dir)
+	ass.Contains(names, "app-worker.log")
+	ass.Contains(names, "app-20200501.log")
+	ass.Len(names, 4)
+}
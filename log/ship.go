@@ -0,0 +1,359 @@
+package log
+
+import (
+	"encoding/binary"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/sanbsy/gopkg/errors"
+)
+
+// ShipperOptions 日志发送配置
+type ShipperOptions struct {
+	// 内存中缓冲的最大日志条数，默认 1024
+	BufferSize int `json:"buffer_size" yaml:"buffer_size" mapstructure:"buffer_size"`
+
+	// 每次发送的最大日志条数，默认 100
+	BatchSize int `json:"batch_size" yaml:"batch_size" mapstructure:"batch_size"`
+
+	// 定时发送间隔，默认 1s
+	FlushInterval Duration `json:"flush_interval" yaml:"flush_interval" mapstructure:"flush_interval"`
+
+	// 发送失败后的重试间隔，从 MinBackoff 开始倍增至 MaxBackoff，默认 100ms 与 30s
+	MinBackoff Duration `json:"min_backoff" yaml:"min_backoff" mapstructure:"min_backoff"`
+	MaxBackoff Duration `json:"max_backoff" yaml:"max_backoff" mapstructure:"max_backoff"`
+
+	// 缓冲区满时写入的本地文件，为空时丢弃最早的日志；
+	// 文件中的日志在恢复连接后优先发送，进程重启后会重新发送，可能存在重复
+	SpillPath string `json:"spill_path" yaml:"spill_path" mapstructure:"spill_path"`
+
+	// 本地文件的最大字节数，单位为MB，默认 100
+	MaxSpillSize int `json:"max_spill_size" yaml:"max_spill_size" mapstructure:"max_spill_size"`
+}
+
+func (opts *ShipperOptions) loadDefault() {
+	if opts.BufferSize <= 0 {
+		opts.BufferSize = 1024
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = 100
+	}
+	if opts.FlushInterval <= 0 {
+		opts.FlushInterval = Duration(time.Second)
+	}
+	if opts.MinBackoff <= 0 {
+		opts.MinBackoff = Duration(100 * time.Millisecond)
+	}
+	if opts.MaxBackoff < opts.MinBackoff {
+		opts.MaxBackoff = Duration(30 * time.Second)
+	}
+	if opts.MaxSpillSize <= 0 {
+		opts.MaxSpillSize = 100
+	}
+}
+
+// transport 将一批日志发送到远端
+type transport interface {
+	send(records [][]byte) error
+	close() error
+}
+
+// shipper 缓冲日志并在后台批量发送，发送失败时按退避时间重试，
+// 缓冲区满时将日志转存到本地文件
+type shipper struct {
+	opts ShipperOptions
+	tr   transport
+
+	mu      sync.Mutex
+	queue   [][]byte
+	spill   *spillFile
+	dropped int
+	closed  bool
+
+	// 仅由后台协程访问
+	backoff   time.Duration
+	nextRetry time.Time
+
+	kick    chan struct{}
+	flushCh chan chan error
+	done    chan struct{}
+	stopped chan struct{}
+}
+
+func newShipper(tr transport, opts *ShipperOptions) (*shipper, error) {
+	if opts == nil {
+		opts = &ShipperOptions{}
+	}
+	o := *opts
+	o.loadDefault()
+
+	s := &shipper{
+		opts:    o,
+		tr:      tr,
+		kick:    make(chan struct{}, 1),
+		flushCh: make(chan chan error),
+		done:    make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+	if o.SpillPath != "" {
+		spill, err := openSpillFile(o.SpillPath, int64(o.MaxSpillSize)*1024*1024)
+		if err != nil {
+			return nil, err
+		}
+		s.spill = spill
+	}
+	go s.run()
+	return s, nil
+}
+
+// enqueue 加入发送队列，record 不会被复制
+func (s *shipper) enqueue(record []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		s.dropped++
+		return
+	}
+
+	if len(s.queue) >= s.opts.BufferSize {
+		if s.spill != nil {
+			// 队列中的日志整体转存，保证本地文件中的日志早于队列
+			for _, r := range s.queue {
+				if err := s.spill.append(r); err != nil {
+					s.dropped++
+				}
+			}
+			s.queue = s.queue[:0]
+		} else {
+			n := len(s.queue) - s.opts.BufferSize + 1
+			s.queue = append(s.queue[:0], s.queue[n:]...)
+			s.dropped += n
+		}
+	}
+	s.queue = append(s.queue, record)
+	if len(s.queue) >= s.opts.BatchSize {
+		select {
+		case s.kick <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// sync 立即发送所有日志，忽略退避时间
+func (s *shipper) sync() error {
+	req := make(chan error)
+	select {
+	case s.flushCh <- req:
+		return <-req
+	case <-s.stopped:
+		return nil
+	}
+}
+
+// close 尽力发送剩余日志后关闭，未发送的日志转存到本地文件
+func (s *shipper) close() error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return nil
+	}
+	s.closed = true
+	s.mu.Unlock()
+
+	close(s.done)
+	<-s.stopped
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.spill != nil {
+		for _, r := range s.queue {
+			_ = s.spill.append(r)
+		}
+		_ = s.spill.close()
+	} else {
+		s.dropped += len(s.queue)
+	}
+	s.queue = nil
+	return s.tr.close()
+}
+
+// droppedCount 丢弃的日志条数
+func (s *shipper) droppedCount() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.dropped
+}
+
+func (s *shipper) run() {
+	defer close(s.stopped)
+
+	ticker := time.NewTicker(time.Duration(s.opts.FlushInterval))
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			_ = s.flush(false)
+		case <-s.kick:
+			_ = s.flush(false)
+		case req := <-s.flushCh:
+			req <- s.flush(true)
+		case <-s.done:
+			_ = s.flush(true)
+			return
+		}
+	}
+}
+
+// flush 先发送本地文件中的日志，再发送队列中的日志
+func (s *shipper) flush(force bool) error {
+	if !force && time.Now().Before(s.nextRetry) {
+		return nil
+	}
+	err := s.flushSpill()
+	if err == nil {
+		err = s.flushQueue()
+	}
+
+	if err != nil {
+		if s.backoff == 0 {
+			s.backoff = time.Duration(s.opts.MinBackoff)
+		} else if s.backoff *= 2; s.backoff > time.Duration(s.opts.MaxBackoff) {
+			s.backoff = time.Duration(s.opts.MaxBackoff)
+		}
+		s.nextRetry = time.Now().Add(s.backoff)
+		return err
+	}
+	s.backoff = 0
+	s.nextRetry = time.Time{}
+	return nil
+}
+
+func (s *shipper) flushSpill() error {
+	if s.spill == nil {
+		return nil
+	}
+	for {
+		s.mu.Lock()
+		batch, next, err := s.spill.read(s.opts.BatchSize)
+		s.mu.Unlock()
+		if err != nil || len(batch) == 0 {
+			return err
+		}
+		if err := s.tr.send(batch); err != nil {
+			return err
+		}
+		s.mu.Lock()
+		err = s.spill.commit(next)
+		s.mu.Unlock()
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func (s *shipper) flushQueue() error {
+	for {
+		s.mu.Lock()
+		n := len(s.queue)
+		if n > s.opts.BatchSize {
+			n = s.opts.BatchSize
+		}
+		batch := make([][]byte, n)
+		copy(batch, s.queue)
+		for i := 0; i < n; i++ {
+			s.queue[i] = nil
+		}
+		s.queue = s.queue[n:]
+		s.mu.Unlock()
+		if n == 0 {
+			return nil
+		}
+
+		if err := s.tr.send(batch); err != nil {
+			// 发送失败的日志放回队列头部，超出缓冲区的部分在下次写入时处理
+			s.mu.Lock()
+			s.queue = append(batch, s.queue...)
+			s.mu.Unlock()
+			return err
+		}
+	}
+}
+
+// spillFile 以长度前缀格式保存日志的本地文件
+type spillFile struct {
+	file    *os.File
+	maxSize int64
+	size    int64
+	// offset 已发送的位置
+	offset int64
+}
+
+func openSpillFile(path string, maxSize int64) (*spillFile, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return nil, err
+	}
+	return &spillFile{file: file, maxSize: maxSize, size: info.Size()}, nil
+}
+
+var errSpillFull = errors.New("log: spill file is full")
+
+func (f *spillFile) append(record []byte) error {
+	if f.size+int64(len(record))+4 > f.maxSize {
+		return errSpillFull
+	}
+	buf := make([]byte, 4+len(record))
+	binary.BigEndian.PutUint32(buf, uint32(len(record)))
+	copy(buf[4:], record)
+	n, err := f.file.WriteAt(buf, f.size)
+	f.size += int64(n)
+	return err
+}
+
+// read 从已发送的位置读取至多 max 条日志，返回读取结束的位置
+func (f *spillFile) read(max int) ([][]byte, int64, error) {
+	var (
+		records [][]byte
+		offset  = f.offset
+		header  [4]byte
+	)
+	for len(records) < max && offset+4 <= f.size {
+		if _, err := f.file.ReadAt(header[:], offset); err != nil {
+			return nil, 0, err
+		}
+		n := int64(binary.BigEndian.Uint32(header[:]))
+		if offset+4+n > f.size {
+			// 不完整的记录，丢弃文件尾部
+			f.size = offset
+			break
+		}
+		record := make([]byte, n)
+		if _, err := f.file.ReadAt(record, offset+4); err != nil {
+			return nil, 0, err
+		}
+		records = append(records, record)
+		offset += 4 + n
+	}
+	return records, offset, nil
+}
+
+// commit 标记 offset 之前的日志已发送，全部发送后清空文件
+func (f *spillFile) commit(offset int64) error {
+	f.offset = offset
+	if f.offset < f.size {
+		return nil
+	}
+	f.offset, f.size = 0, 0
+	return f.file.Truncate(0)
+}
+
+func (f *spillFile) close() error {
+	return f.file.Close()
+}
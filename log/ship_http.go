@@ -0,0 +1,115 @@
+package log
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/sanbsy/gopkg/errors"
+)
+
+type (
+	// HTTPOptions HTTP 批量发送配置
+	HTTPOptions struct {
+		// 接收日志的地址，以 POST 方式发送换行分隔的日志
+		URL string `json:"url" yaml:"url" mapstructure:"url"`
+
+		// 附加的请求头
+		Headers map[string]string `json:"headers" yaml:"headers" mapstructure:"headers"`
+
+		// 请求超时，默认 10s
+		Timeout Duration `json:"timeout" yaml:"timeout" mapstructure:"timeout"`
+
+		// 是否关闭 gzip 压缩
+		DisableGzip bool `json:"disable_gzip" yaml:"disable_gzip" mapstructure:"disable_gzip"`
+
+		// 缓冲与重试配置
+		Shipper ShipperOptions `json:"shipper" yaml:"shipper" mapstructure:"shipper"`
+	}
+
+	// HTTPWriter 以 HTTP POST 批量发送日志，响应状态码不是 2xx 时重试
+	HTTPWriter struct {
+		shipWriter
+	}
+)
+
+// NewHTTPWriter 创建 HTTPWriter
+func NewHTTPWriter(opts *HTTPOptions) (*HTTPWriter, error) {
+	if opts == nil || opts.URL == "" {
+		return nil, errors.New("log: http writer requires a url")
+	}
+	timeout := time.Duration(opts.Timeout)
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+	tr := &httpTransport{
+		url:     opts.URL,
+		headers: opts.Headers,
+		gzip:    !opts.DisableGzip,
+		client:  &http.Client{Timeout: timeout},
+	}
+	s, err := newShipper(tr, &opts.Shipper)
+	if err != nil {
+		return nil, err
+	}
+	return &HTTPWriter{shipWriter{s: s}}, nil
+}
+
+type httpTransport struct {
+	url     string
+	headers map[string]string
+	gzip    bool
+	client  *http.Client
+}
+
+func (t *httpTransport) send(records [][]byte) error {
+	var body bytes.Buffer
+	var w io.Writer = &body
+	var zw *gzip.Writer
+	if t.gzip {
+		zw = gzip.NewWriter(&body)
+		w = zw
+	}
+	for _, record := range records {
+		_, _ = w.Write(record)
+		if !bytes.HasSuffix(record, []byte{'\n'}) {
+			_, _ = w.Write([]byte{'\n'})
+		}
+	}
+	if zw != nil {
+		if err := zw.Close(); err != nil {
+			return err
+		}
+	}
+
+	req, err := http.NewRequest(http.MethodPost, t.url, &body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-ndjson")
+	if t.gzip {
+		req.Header.Set("Content-Encoding", "gzip")
+	}
+	for key, value := range t.headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(ioutil.Discard, resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return errors.Errorf("log: ship logs to %s: unexpected status %s", t.url, resp.Status)
+	}
+	return nil
+}
+
+func (t *httpTransport) close() error {
+	t.client.CloseIdleConnections()
+	return nil
+}
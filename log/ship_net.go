@@ -0,0 +1,283 @@
+package log
+
+import (
+	"bytes"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/sanbsy/gopkg/bufferpool"
+	"github.com/sanbsy/gopkg/errors"
+)
+
+const defaultDialTimeout = 5 * time.Second
+
+type (
+	// TCPOptions TCP 日志发送配置
+	TCPOptions struct {
+		// 日志收集服务地址
+		Address string `json:"address" yaml:"address" mapstructure:"address"`
+
+		// 连接与写入超时，默认 5s
+		Timeout Duration `json:"timeout" yaml:"timeout" mapstructure:"timeout"`
+
+		// 缓冲与重试配置
+		Shipper ShipperOptions `json:"shipper" yaml:"shipper" mapstructure:"shipper"`
+	}
+
+	// SyslogOptions RFC 5424 syslog 发送配置
+	SyslogOptions struct {
+		// 网络类型，可选 udp、tcp、unix、unixgram，默认 udp
+		Network string `json:"network" yaml:"network" mapstructure:"network"`
+
+		// syslog 服务地址，unix 类型为 socket 文件路径
+		Address string `json:"address" yaml:"address" mapstructure:"address"`
+
+		// facility，取值 0-23，为空时使用 1 (user-level)
+		Facility *int `json:"facility" yaml:"facility" mapstructure:"facility"`
+
+		// APP-NAME，默认为程序名
+		AppName string `json:"app_name" yaml:"app_name" mapstructure:"app_name"`
+
+		// HOSTNAME，默认为主机名
+		Hostname string `json:"hostname" yaml:"hostname" mapstructure:"hostname"`
+
+		// 连接与写入超时，默认 5s
+		Timeout Duration `json:"timeout" yaml:"timeout" mapstructure:"timeout"`
+
+		// 缓冲与重试配置
+		Shipper ShipperOptions `json:"shipper" yaml:"shipper" mapstructure:"shipper"`
+	}
+
+	// TCPWriter 通过 TCP 发送以换行分隔的日志，断开后自动重连
+	TCPWriter struct {
+		shipWriter
+	}
+
+	// SyslogWriter 以 RFC 5424 格式发送日志到 syslog 服务
+	// 日志等级从 JSON 或 console 格式的日志中解析
+	SyslogWriter struct {
+		shipWriter
+		opts     SyslogOptions
+		facility int
+		pid      int
+	}
+)
+
+// shipWriter 写入时复制日志并加入发送队列
+type shipWriter struct {
+	s *shipper
+}
+
+// Write 实现 io.Writer，不会阻塞
+func (w *shipWriter) Write(p []byte) (int, error) {
+	record := make([]byte, len(p))
+	copy(record, p)
+	w.s.enqueue(record)
+	return len(p), nil
+}
+
+// Sync 立即发送缓冲的日志，返回发送错误
+func (w *shipWriter) Sync() error {
+	return w.s.sync()
+}
+
+// Close 发送剩余日志后关闭连接
+func (w *shipWriter) Close() error {
+	return w.s.close()
+}
+
+// Dropped 缓冲区满或关闭后丢弃的日志条数
+func (w *shipWriter) Dropped() int {
+	return w.s.droppedCount()
+}
+
+// NewTCPWriter 创建 TCPWriter，首次发送时建立连接
+func NewTCPWriter(opts *TCPOptions) (*TCPWriter, error) {
+	if opts == nil || opts.Address == "" {
+		return nil, errors.New("log: tcp writer requires an address")
+	}
+	tr := &connTransport{
+		network: "tcp",
+		address: opts.Address,
+		timeout: time.Duration(opts.Timeout),
+		frame: func(buf *bufferpool.Buffer, record []byte) {
+			buf.WriteBytes(record)
+			if !bytes.HasSuffix(record, []byte{'\n'}) {
+				buf.WriteByte('\n')
+			}
+		},
+	}
+	s, err := newShipper(tr, &opts.Shipper)
+	if err != nil {
+		return nil, err
+	}
+	return &TCPWriter{shipWriter{s: s}}, nil
+}
+
+// NewSyslogWriter 创建 SyslogWriter，首次发送时建立连接
+// tcp 与 unix 使用 octet counting 分帧，udp 与 unixgram 每条日志一个数据报
+func NewSyslogWriter(opts *SyslogOptions) (*SyslogWriter, error) {
+	if opts == nil || opts.Address == "" {
+		return nil, errors.New("log: syslog writer requires an address")
+	}
+	o := *opts
+	if o.Network == "" {
+		o.Network = "udp"
+	}
+	facility := 1
+	if o.Facility != nil {
+		facility = *o.Facility
+	}
+	if facility < 0 || facility > 23 {
+		return nil, errors.Errorf("log: invalid syslog facility %d", facility)
+	}
+	if o.AppName == "" {
+		o.AppName = filepath.Base(os.Args[0])
+	}
+	if o.Hostname == "" {
+		o.Hostname, _ = os.Hostname()
+	}
+
+	tr := &connTransport{
+		network:  o.Network,
+		address:  o.Address,
+		timeout:  time.Duration(o.Timeout),
+		datagram: o.Network == "udp" || o.Network == "unixgram",
+		frame: func(buf *bufferpool.Buffer, record []byte) {
+			buf.WriteInt(len(record))
+			buf.WriteByte(' ')
+			buf.WriteBytes(record)
+		},
+	}
+	s, err := newShipper(tr, &o.Shipper)
+	if err != nil {
+		return nil, err
+	}
+	return &SyslogWriter{shipWriter: shipWriter{s: s}, opts: o, facility: facility, pid: os.Getpid()}, nil
+}
+
+// Write 实现 io.Writer，将一条日志转换为 syslog 消息后加入发送队列
+func (w *SyslogWriter) Write(p []byte) (int, error) {
+	buf := bufferpool.Get()
+	defer buf.Free()
+
+	buf.WriteByte('<')
+	buf.WriteInt(w.facility*8 + syslogSeverity(parseLineLevel(p)))
+	buf.WriteString(">1 ")
+	buf.WriteTime(currentTime(), "2006-01-02T15:04:05.000000Z07:00")
+	buf.WriteByte(' ')
+	buf.WriteString(syslogField(w.opts.Hostname))
+	buf.WriteByte(' ')
+	buf.WriteString(syslogField(w.opts.AppName))
+	buf.WriteByte(' ')
+	buf.WriteInt(w.pid)
+	buf.WriteString(" - - ")
+	buf.WriteBytes(bytes.TrimRight(p, "\n"))
+
+	record := make([]byte, buf.Len())
+	copy(record, buf.Bytes())
+	w.s.enqueue(record)
+	return len(p), nil
+}
+
+// syslogField 空值使用 NILVALUE
+func syslogField(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+// syslogSeverity 日志等级对应的 syslog severity
+func syslogSeverity(level Level) int {
+	switch level {
+	case DebugLevel:
+		return 7
+	case InfoLevel:
+		return 6
+	case WarnLevel:
+		return 4
+	case ErrorLevel:
+		return 3
+	}
+	return 2
+}
+
+// parseLineLevel 从 JSON 或 console 格式的日志中解析日志等级，失败时返回 InfoLevel
+func parseLineLevel(line []byte) Level {
+	var text []byte
+	key := []byte(`"` + NewDefaultEncoderConfig().LevelKey + `":"`)
+	if i := bytes.Index(line, key); i >= 0 {
+		text = line[i+len(key):]
+		if j := bytes.IndexByte(text, '"'); j >= 0 {
+			text = text[:j]
+		}
+	} else if fields := bytes.SplitN(line, []byte{'\t'}, 3); len(fields) == 3 {
+		text = fields[1]
+	}
+
+	level := InfoLevel
+	if err := level.UnmarshalText(text); err != nil {
+		return InfoLevel
+	}
+	return level
+}
+
+// connTransport 基于 net.Conn 发送日志，出错时关闭连接并在下次发送时重连
+type connTransport struct {
+	network  string
+	address  string
+	timeout  time.Duration
+	datagram bool
+	frame    func(buf *bufferpool.Buffer, record []byte)
+
+	conn net.Conn
+}
+
+func (t *connTransport) send(records [][]byte) error {
+	timeout := t.timeout
+	if timeout <= 0 {
+		timeout = defaultDialTimeout
+	}
+	if t.conn == nil {
+		conn, err := net.DialTimeout(t.network, t.address, timeout)
+		if err != nil {
+			return err
+		}
+		t.conn = conn
+	}
+
+	err := t.conn.SetWriteDeadline(time.Now().Add(timeout))
+	if err == nil {
+		if t.datagram {
+			for _, record := range records {
+				if _, err = t.conn.Write(record); err != nil {
+					break
+				}
+			}
+		} else {
+			buf := bufferpool.Get()
+			for _, record := range records {
+				t.frame(buf, record)
+			}
+			_, err = t.conn.Write(buf.Bytes())
+			buf.Free()
+		}
+	}
+	if err != nil {
+		_ = t.conn.Close()
+		t.conn = nil
+	}
+	return err
+}
+
+func (t *connTransport) close() error {
+	if t.conn == nil {
+		return nil
+	}
+	err := t.conn.Close()
+	t.conn = nil
+	return err
+}
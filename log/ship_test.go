@@ -0,0 +1,261 @@
+package log
+
+import (
+	"bufio"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// acceptLines 接收 TCP 连接中的所有行
+func acceptLines(ln net.Listener) <-chan string {
+	lines := make(chan string, 100)
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				scanner := bufio.NewScanner(conn)
+				for scanner.Scan() {
+					lines <- scanner.Text()
+				}
+			}()
+		}
+	}()
+	return lines
+}
+
+func receive(t *testing.T, ch <-chan string, n int) []string {
+	var got []string
+	for len(got) < n {
+		select {
+		case s := <-ch:
+			got = append(got, s)
+		case <-time.After(3 * time.Second):
+			t.Fatalf("received %d of %d records: %v", len(got), n, got)
+		}
+	}
+	return got
+}
+
+func TestTCPWriter(t *testing.T) {
+	ass := assert.New(t)
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.Nil(t, err)
+	defer ln.Close()
+	lines := acceptLines(ln)
+
+	w, err := NewTCPWriter(&TCPOptions{Address: ln.Addr().String(), Shipper: ShipperOptions{FlushInterval: Duration(time.Hour)}})
+	require.Nil(t, err)
+	defer w.Close()
+
+	logger := NewLogger(JSONFormat, FatalLevel).WithOutput(w, InfoLevel, JSONFormat)
+	logger.Info("first")
+	logger.Info("second")
+	logger.Sync()
+
+	got := receive(t, lines, 2)
+	ass.Contains(got[0], `"@message":"first"`)
+	ass.Contains(got[1], `"@message":"second"`)
+}
+
+func TestTCPWriter_Spill(t *testing.T) {
+	ass := assert.New(t)
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.Nil(t, err)
+	addr := ln.Addr().String()
+	ln.Close()
+
+	w, err := NewTCPWriter(&TCPOptions{
+		Address: addr,
+		Shipper: ShipperOptions{
+			BufferSize:    2,
+			FlushInterval: Duration(time.Hour),
+			SpillPath:     filepath.Join(t.TempDir(), "spill.dat"),
+		},
+	})
+	require.Nil(t, err)
+	defer w.Close()
+
+	for i := 0; i < 5; i++ {
+		_, _ = w.Write([]byte("record " + strconv.Itoa(i) + "\n"))
+	}
+	ass.NotNil(w.Sync())
+	ass.Equal(0, w.Dropped())
+
+	ln, err = net.Listen("tcp", addr)
+	require.Nil(t, err)
+	defer ln.Close()
+	lines := acceptLines(ln)
+
+	ass.Nil(w.Sync())
+	ass.Equal([]string{"record 0", "record 1", "record 2", "record 3", "record 4"}, receive(t, lines, 5))
+}
+
+func TestShipper_DropOldest(t *testing.T) {
+	ass := assert.New(t)
+	w, err := NewTCPWriter(&TCPOptions{
+		Address: "127.0.0.1:1",
+		Shipper: ShipperOptions{BufferSize: 2, FlushInterval: Duration(time.Hour)},
+	})
+	require.Nil(t, err)
+
+	for i := 0; i < 3; i++ {
+		_, _ = w.Write([]byte("x\n"))
+	}
+	ass.Equal(1, w.Dropped())
+	ass.Nil(w.Close())
+	ass.Equal(3, w.Dropped())
+}
+
+func TestSyslogWriter(t *testing.T) {
+	ass := assert.New(t)
+
+	t.Run("udp", func(t *testing.T) {
+		conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+		require.Nil(t, err)
+		defer conn.Close()
+
+		w, err := NewSyslogWriter(&SyslogOptions{Address: conn.LocalAddr().String(), AppName: "demo", Hostname: "host"})
+		require.Nil(t, err)
+		defer w.Close()
+
+		logger := NewLogger(JSONFormat, FatalLevel).WithOutput(w, InfoLevel, JSONFormat)
+		logger.Error("disk full")
+		logger.Sync()
+
+		buf := make([]byte, 4096)
+		_ = conn.SetReadDeadline(time.Now().Add(3 * time.Second))
+		n, _, err := conn.ReadFrom(buf)
+		require.Nil(t, err)
+		msg := string(buf[:n])
+		ass.True(strings.HasPrefix(msg, "<11>1 "), msg)
+		ass.Contains(msg, " host demo ")
+		ass.Contains(msg, `"@message":"disk full"}`)
+	})
+
+	t.Run("tcp", func(t *testing.T) {
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		require.Nil(t, err)
+		defer ln.Close()
+
+		facility := 16
+		w, err := NewSyslogWriter(&SyslogOptions{Network: "tcp", Address: ln.Addr().String(), Facility: &facility})
+		require.Nil(t, err)
+		defer w.Close()
+
+		logger := NewLogger(JSONFormat, FatalLevel).WithOutput(w, DebugLevel, TextFormat)
+		logger.Warn("one")
+		logger.Debug("two")
+		logger.Sync()
+
+		conn, err := ln.Accept()
+		require.Nil(t, err)
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+		for _, expect := range []string{"<132>1 ", "<135>1 "} {
+			size, err := r.ReadString(' ')
+			require.Nil(t, err)
+			n, err := strconv.Atoi(strings.TrimSpace(size))
+			require.Nil(t, err)
+			msg := make([]byte, n)
+			_, err = io.ReadFull(r, msg)
+			require.Nil(t, err)
+			ass.True(strings.HasPrefix(string(msg), expect), string(msg))
+		}
+	})
+
+	t.Run("kern", func(t *testing.T) {
+		conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+		require.Nil(t, err)
+		defer conn.Close()
+
+		kern := 0
+		w, err := NewSyslogWriter(&SyslogOptions{Address: conn.LocalAddr().String(), Facility: &kern})
+		require.Nil(t, err)
+		defer w.Close()
+
+		NewLogger(JSONFormat, FatalLevel).WithOutput(w, InfoLevel, JSONFormat).Error("panic")
+		ass.Nil(w.Sync())
+
+		buf := make([]byte, 4096)
+		_ = conn.SetReadDeadline(time.Now().Add(3 * time.Second))
+		n, _, err := conn.ReadFrom(buf)
+		require.Nil(t, err)
+		ass.True(strings.HasPrefix(string(buf[:n]), "<3>1 "), string(buf[:n]))
+
+		invalid := 24
+		_, err = NewSyslogWriter(&SyslogOptions{Address: conn.LocalAddr().String(), Facility: &invalid})
+		ass.NotNil(err)
+	})
+}
+
+func TestShipWriter_NilOptions(t *testing.T) {
+	ass := assert.New(t)
+	_, err := NewTCPWriter(nil)
+	ass.NotNil(err)
+	_, err = NewSyslogWriter(nil)
+	ass.NotNil(err)
+	_, err = NewHTTPWriter(nil)
+	ass.NotNil(err)
+	_, err = NewHTTPWriter(&HTTPOptions{})
+	ass.NotNil(err)
+}
+
+func TestHTTPWriter(t *testing.T) {
+	ass := assert.New(t)
+	var (
+		mu       sync.Mutex
+		failures = 1
+		received []string
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if failures > 0 {
+			failures--
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		ass.Equal("gzip", r.Header.Get("Content-Encoding"))
+		ass.Equal("secret", r.Header.Get("X-Api-Key"))
+		zr, err := gzip.NewReader(r.Body)
+		require.Nil(t, err)
+		data, err := ioutil.ReadAll(zr)
+		require.Nil(t, err)
+		received = append(received, strings.Split(strings.TrimSpace(string(data)), "\n")...)
+	}))
+	defer server.Close()
+
+	w, err := NewHTTPWriter(&HTTPOptions{
+		URL:     server.URL,
+		Headers: map[string]string{"X-Api-Key": "secret"},
+		Shipper: ShipperOptions{FlushInterval: Duration(time.Hour)},
+	})
+	require.Nil(t, err)
+	defer w.Close()
+
+	_, _ = w.Write([]byte(`{"n":1}` + "\n"))
+	_, _ = w.Write([]byte(`{"n":2}`))
+	ass.NotNil(w.Sync())
+	ass.Nil(w.Sync())
+
+	mu.Lock()
+	defer mu.Unlock()
+	ass.Equal([]string{`{"n":1}`, `{"n":2}`}, received)
+}
@@ -2,163 +2,218 @@ package log
 
 import (
 	"context"
-)
+	"sync/atomic"
 
-const (
-	// SampleCallerSkipOffset 通过内置 logger 输出日志， Caller + 1
-	SampleCallerSkipOffset = 1
+	"go.uber.org/zap/zapcore"
 )
 
-var (
-	defaultLogger = NewLogger(JSONFormat, InfoLevel)
-	simpleLogger  = defaultLogger.AddCallerSkip(SampleCallerSkipOffset)
-)
+// SampleCallerSkipOffset 通过内置 logger 输出日志， Caller + 1
+//
+// Deprecated: 日志位置根据调用栈自动计算，包级函数不再需要额外的偏移
+const SampleCallerSkipOffset = 1
 
-var (
-	// Debug 内置 logger 以 debug 等级输出日志
-	Debug = simpleLogger.Debug
-	// DebugF 通过内置 logger， 以 debug 等级格式化输出日志
-	DebugF = simpleLogger.DebugF
-	// DebugWithFields 通过内置 logger， 以 debug 等级输出日志，并附加 fields 信息
-	DebugWithFields = simpleLogger.DebugWithField
-
-	// Info 内置 logger 以 info 等级输出日志
-	Info = simpleLogger.Info
-	// InfoF 通过内置 logger， 以 info 等级格式化输出日志
-	InfoF = simpleLogger.InfoF
-	// InfoWithFields 通过内置 logger， 以 info 等级输出日志，并附加 fields 信息
-	InfoWithFields = simpleLogger.InfoWithField
-
-	// Warn 内置 logger 以 warn 等级输出日志
-	Warn = simpleLogger.Warn
-	// WarnF 通过内置 logger， 以 warn 等级格式化输出日志
-	WarnF = simpleLogger.WarnF
-	// WarnWithFields 通过内置 logger， 以 warn 等级输出日志，并附加 fields 信息
-	WarnWithFields = simpleLogger.WarnWithField
-
-	// Error 内置 logger 以 error 等级输出日志
-	Error = simpleLogger.Error
-	// ErrorF 通过内置 logger， 以 error 等级格式化输出日志
-	ErrorF = simpleLogger.ErrorF
-	// ErrorWithFields 通过内置 logger， 以 error 等级输出日志，并附加 fields 信息
-	ErrorWithFields = simpleLogger.ErrorWithField
-)
+var std atomic.Value
+
+func init() {
+	ReplaceDefault(NewLogger(JSONFormat, InfoLevel))
+}
+
+// Default 获取内置 logger
+func Default() *Logger {
+	return std.Load().(*Logger)
+}
+
+// ReplaceDefault 替换内置 logger，返回恢复原 logger 的函数
+func ReplaceDefault(logger *Logger) (restore func()) {
+	prev, _ := std.Load().(*Logger)
+	std.Store(logger)
+	return func() {
+		if prev != nil {
+			std.Store(prev)
+		}
+	}
+}
+
+// Debug 内置 logger 以 debug 等级输出日志
+func Debug(message string) {
+	Default().Debug(message)
+}
+
+// DebugF 通过内置 logger， 以 debug 等级格式化输出日志
+func DebugF(format string, args ...interface{}) {
+	Default().DebugF(format, args...)
+}
+
+// DebugWithFields 通过内置 logger， 以 debug 等级输出日志，并附加 fields 信息
+func DebugWithFields(message string, fields Fields) {
+	Default().DebugWithField(message, fields)
+}
+
+// Info 内置 logger 以 info 等级输出日志
+func Info(message string) {
+	Default().Info(message)
+}
+
+// InfoF 通过内置 logger， 以 info 等级格式化输出日志
+func InfoF(format string, args ...interface{}) {
+	Default().InfoF(format, args...)
+}
+
+// InfoWithFields 通过内置 logger， 以 info 等级输出日志，并附加 fields 信息
+func InfoWithFields(message string, fields Fields) {
+	Default().InfoWithField(message, fields)
+}
+
+// Warn 内置 logger 以 warn 等级输出日志
+func Warn(message string) {
+	Default().Warn(message)
+}
+
+// WarnF 通过内置 logger， 以 warn 等级格式化输出日志
+func WarnF(format string, args ...interface{}) {
+	Default().WarnF(format, args...)
+}
+
+// WarnWithFields 通过内置 logger， 以 warn 等级输出日志，并附加 fields 信息
+func WarnWithFields(message string, fields Fields) {
+	Default().WarnWithField(message, fields)
+}
+
+// Error 内置 logger 以 error 等级输出日志
+func Error(message string) {
+	Default().Error(message)
+}
+
+// ErrorF 通过内置 logger， 以 error 等级格式化输出日志
+func ErrorF(format string, args ...interface{}) {
+	Default().ErrorF(format, args...)
+}
+
+// ErrorWithFields 通过内置 logger， 以 error 等级输出日志，并附加 fields 信息
+func ErrorWithFields(message string, fields Fields) {
+	Default().ErrorWithField(message, fields)
+}
 
 // SetLevel 设置默认 logger 输出级别
 func SetLevel(level Level) {
-	defaultLogger.SetLevel(level)
+	Default().SetLevel(level)
 }
 
 // DebugCtx 通过 ctx 获取 logger， 并以 debug 等级输出日志
 func DebugCtx(ctx context.Context, message string) {
-	loadLogger(ctx).Debug(message)
+	loadLogger(ctx, DebugLevel).Debug(message)
 }
 
 // DebugCtxF 通过 ctx 获取 logger， 并以 debug 等级格式化输出日志
 func DebugCtxF(ctx context.Context, format string, args ...interface{}) {
-	loadLogger(ctx).DebugF(format, args...)
+	loadLogger(ctx, DebugLevel).DebugF(format, args...)
 }
 
 // DebugCtxWithFields 通过 ctx 获取 logger， 以 debug 等级输出日志，并附加 fields 信息
 func DebugCtxWithFields(ctx context.Context, message string, fields Fields) {
-	loadLogger(ctx).DebugWithField(message, fields)
+	loadLogger(ctx, DebugLevel).DebugWithField(message, fields)
 }
 
 func InfoCtx(ctx context.Context, message string) {
-	loadLogger(ctx).Info(message)
+	loadLogger(ctx, InfoLevel).Info(message)
 }
 func InfoCtxF(ctx context.Context, format string, args ...interface{}) {
-	loadLogger(ctx).InfoF(format, args...)
+	loadLogger(ctx, InfoLevel).InfoF(format, args...)
 }
 
 func InfoCtxWithFields(ctx context.Context, message string, fields Fields) {
-	loadLogger(ctx).InfoWithField(message, fields)
+	loadLogger(ctx, InfoLevel).InfoWithField(message, fields)
 }
 
 func WarnCtx(ctx context.Context, message string) {
-	loadLogger(ctx).Warn(message)
+	loadLogger(ctx, WarnLevel).Warn(message)
 }
 
 func WarnCtxF(ctx context.Context, format string, args ...interface{}) {
-	loadLogger(ctx).WarnF(format, args...)
+	loadLogger(ctx, WarnLevel).WarnF(format, args...)
 }
 
 func WarnCtxWithFields(ctx context.Context, message string, fields Fields) {
-	loadLogger(ctx).WarnWithField(message, fields)
+	loadLogger(ctx, WarnLevel).WarnWithField(message, fields)
 }
 
 func ErrorCtx(ctx context.Context, message string) {
-	loadLogger(ctx).Error(message)
+	loadLogger(ctx, ErrorLevel).Error(message)
 }
 
 func ErrorCtxF(ctx context.Context, format string, args ...interface{}) {
-	loadLogger(ctx).ErrorF(format, args...)
+	loadLogger(ctx, ErrorLevel).ErrorF(format, args...)
 }
 
 func ErrorCtxWithFields(ctx context.Context, message string, fields Fields) {
-	loadLogger(ctx).ErrorWithField(message, fields)
+	loadLogger(ctx, ErrorLevel).ErrorWithField(message, fields)
 }
 
 func Panic(message string) {
-	simpleLogger.Panic(message)
+	Default().Panic(message)
 }
 func PanicCtx(ctx context.Context, message string) {
-	loadLogger(ctx).Panic(message)
+	loadLogger(ctx, PanicLevel).Panic(message)
 }
 
 // PanicF 通过内置 logger，以 panic 等级格式化输出日志
 func PanicF(format string, args ...interface{}) {
-	simpleLogger.PanicF(format, args...)
+	Default().PanicF(format, args...)
 }
 
 // PanicCtxF 通过 ctx 获取 logger，以 panic 等级格式化输出日志
 func PanicCtxF(ctx context.Context, format string, args ...interface{}) {
-	loadLogger(ctx).PanicF(format, args...)
+	loadLogger(ctx, PanicLevel).PanicF(format, args...)
 }
 
 // PanicWithFields 通过内置 logger，以 panic 等级输出日志，并 附加 fields 信息
 func PanicWithFields(message string, fields Fields) {
-	simpleLogger.PanicWithField(message, fields)
+	Default().PanicWithField(message, fields)
 }
 
 // PanicCtxWithFields 通过 ctx  获取logger，以 panic 等级输出日志，并 附加 fields 信息
 func PanicCtxWithFields(ctx context.Context, message string, fields Fields) {
-	loadLogger(ctx).PanicWithField(message, fields)
+	loadLogger(ctx, PanicLevel).PanicWithField(message, fields)
 }
 
 // Fatal 使用内置logger，以 fatal 等级输出日志
 func Fatal(message string) {
-	simpleLogger.Fatal(message)
+	Default().Fatal(message)
 }
 
 // FatalCtx 通过 ctx 获取 logger，以 fatal 等级输出日志
 func FatalCtx(ctx context.Context, message string) {
-	loadLogger(ctx).Fatal(message)
+	loadLogger(ctx, FatalLevel).Fatal(message)
 }
 
 // FatalF 通过 ctx 获取 logger，以 fatal 等级格式化输出日志
 func FatalF(format string, args ...interface{}) {
-	simpleLogger.FatalF(format, args...)
+	Default().FatalF(format, args...)
 }
 
 // FatalCtxF 通过 ctx 获取 logger，以 fatal 等级格式化输出日志
 func FatalCtxF(ctx context.Context, format string, args ...interface{}) {
-	loadLogger(ctx).FatalF(format, args...)
+	loadLogger(ctx, FatalLevel).FatalF(format, args...)
 }
 
 // FatalWithFields 通过 内置 logger，以 fatal 等级输出日志，并 附加 fields 信息
 func FatalWithFields(message string, fields Fields) {
-	simpleLogger.FatalWithField(message, fields)
+	Default().FatalWithField(message, fields)
 }
 
 // FatalCtxWithFields 通过 ctx 获取 logger，以 fatal 等级输出日志，并 附加 fields 信息
 func FatalCtxWithFields(ctx context.Context, message string, fields Fields) {
-	loadLogger(ctx).FatalWithField(message, fields)
+	loadLogger(ctx, FatalLevel).FatalWithField(message, fields)
 }
 
-func loadLogger(ctx context.Context) *Logger {
-	if logger := extractLogger(ctx); logger != nil {
-		return logger.AddCallerSkip(1)
+// loadLogger 获取 ctx 中的 logger，level 未开启时跳过附加 ctx Fields 的开销
+func loadLogger(ctx context.Context, level zapcore.Level) *Logger {
+	logger := extractLogger(ctx)
+	if logger == nil {
+		logger = Default()
+	}
+	if !logger.logger.Core().Enabled(level) {
+		return logger
 	}
-	return simpleLogger
+	return logger.WithContext(ctx)
 }
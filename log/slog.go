@@ -0,0 +1,178 @@
+//go:build go1.21
+// +build go1.21
+
+package log
+
+import (
+	"context"
+	stdlog "log"
+	"log/slog"
+	"runtime"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// SlogHandler 以 Logger 输出日志的 slog.Handler
+// slog 的 group 以 NewNamespace 的形式输出
+type SlogHandler struct {
+	logger *Logger
+
+	// root 与 ops 用于在 group 之外附加 ctx 中的日志字段
+	root *Logger
+	ops  []func(*Logger) *Logger
+}
+
+// NewSlogHandler 创建 slog.Handler，日志写入 l
+func NewSlogHandler(l *Logger) *SlogHandler {
+	return &SlogHandler{logger: l, root: l}
+}
+
+func (h *SlogHandler) with(op func(*Logger) *Logger) *SlogHandler {
+	ops := make([]func(*Logger) *Logger, 0, len(h.ops)+1)
+	ops = append(append(ops, h.ops...), op)
+	return &SlogHandler{logger: op(h.logger), root: h.root, ops: ops}
+}
+
+// withContext 附加 ctx 中的日志字段，存在 group 时重新派生以保证字段位于顶层
+func (h *SlogHandler) withContext(ctx context.Context) *Logger {
+	if ctx == nil || len(ExtractFields(ctx)) == 0 {
+		return h.logger
+	}
+	logger := h.root.WithContext(ctx)
+	for _, op := range h.ops {
+		logger = op(logger)
+	}
+	return logger
+}
+
+// Enabled 实现 slog.Handler
+func (h *SlogHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return h.logger.logger.Core().Enabled(fromSlogLevel(level))
+}
+
+// Handle 实现 slog.Handler，日志位置使用 slog 记录的调用位置，并附加 ctx 中的日志字段
+func (h *SlogHandler) Handle(ctx context.Context, r slog.Record) error {
+	logger := h.withContext(ctx)
+	ce := logger.logger.Check(fromSlogLevel(r.Level), r.Message)
+	if ce == nil {
+		return nil
+	}
+	if !r.Time.IsZero() {
+		ce.Entry.Time = r.Time
+	}
+	if logger.addCaller {
+		if r.PC != 0 {
+			frame, _ := runtime.CallersFrames([]uintptr{r.PC}).Next()
+			ce.Entry.Caller = zapcore.NewEntryCaller(frame.PC, frame.File, frame.Line, true)
+		} else {
+			ce.Entry.Caller = logger.caller()
+		}
+	}
+
+	fields := make([]zap.Field, 0, r.NumAttrs())
+	r.Attrs(func(attr slog.Attr) bool {
+		fields = appendAttr(fields, attr)
+		return true
+	})
+	ce.Write(fields...)
+	return nil
+}
+
+// WithAttrs 实现 slog.Handler
+func (h *SlogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	fields := make([]zap.Field, 0, len(attrs))
+	for _, attr := range attrs {
+		fields = appendAttr(fields, attr)
+	}
+	return h.with(func(l *Logger) *Logger {
+		return l.derive(l.logger.With(fields...))
+	})
+}
+
+// WithGroup 实现 slog.Handler
+func (h *SlogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	return h.with(func(l *Logger) *Logger {
+		return l.NewNamespace(name)
+	})
+}
+
+// RedirectSlog 将 slog 默认 logger 的输出写入 l，返回恢复原配置的函数
+func RedirectSlog(l *Logger) (restore func()) {
+	prev := slog.Default()
+	flags, writer := stdlog.Flags(), stdlog.Writer()
+	slog.SetDefault(slog.New(NewSlogHandler(l)))
+	return func() {
+		slog.SetDefault(prev)
+		// slog.SetDefault 会修改标准库 logger 的输出，一并恢复
+		stdlog.SetFlags(flags)
+		stdlog.SetOutput(writer)
+	}
+}
+
+// fromSlogLevel 将 slog 日志等级转换为最接近的 Level
+func fromSlogLevel(level slog.Level) Level {
+	switch {
+	case level < slog.LevelInfo:
+		return DebugLevel
+	case level < slog.LevelWarn:
+		return InfoLevel
+	case level < slog.LevelError:
+		return WarnLevel
+	}
+	return ErrorLevel
+}
+
+func appendAttr(fields []zap.Field, attr slog.Attr) []zap.Field {
+	attr.Value = attr.Value.Resolve()
+	if attr.Equal(slog.Attr{}) {
+		return fields
+	}
+
+	v := attr.Value
+	switch v.Kind() {
+	case slog.KindString:
+		return append(fields, zap.String(attr.Key, v.String()))
+	case slog.KindInt64:
+		return append(fields, zap.Int64(attr.Key, v.Int64()))
+	case slog.KindUint64:
+		return append(fields, zap.Uint64(attr.Key, v.Uint64()))
+	case slog.KindFloat64:
+		return append(fields, zap.Float64(attr.Key, v.Float64()))
+	case slog.KindBool:
+		return append(fields, zap.Bool(attr.Key, v.Bool()))
+	case slog.KindDuration:
+		return append(fields, zap.Duration(attr.Key, v.Duration()))
+	case slog.KindTime:
+		return append(fields, zap.Time(attr.Key, v.Time()))
+	case slog.KindGroup:
+		group := v.Group()
+		if len(group) == 0 {
+			return fields
+		}
+		if attr.Key == "" {
+			for _, a := range group {
+				fields = appendAttr(fields, a)
+			}
+			return fields
+		}
+		return append(fields, zap.Object(attr.Key, slogGroup(group)))
+	}
+	if err, ok := v.Any().(error); ok {
+		return append(fields, zap.NamedError(attr.Key, err))
+	}
+	return append(fields, zap.Any(attr.Key, v.Any()))
+}
+
+// slogGroup 以嵌套对象输出 slog group
+type slogGroup []slog.Attr
+
+func (g slogGroup) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	for _, f := range appendAttr(nil, slog.Attr{Value: slog.GroupValue(g...)}) {
+		f.AddTo(enc)
+	}
+	return nil
+}
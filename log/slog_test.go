@@ -0,0 +1,59 @@
+//go:build go1.21
+// +build go1.21
+
+package log
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestSlogHandler(t *testing.T) {
+	ass := assert.New(t)
+	core, logs := observer.New(DebugLevel)
+	logger := slog.New(NewSlogHandler(NewLoggerWithCore(core, InfoLevel)))
+
+	ctx := WithLogId(context.Background(), "id-1")
+	logger.DebugContext(ctx, "hidden")
+	logger.With("service", "demo").WithGroup("req").InfoContext(ctx, "handled",
+		"status", 200,
+		slog.Group("user", "id", 7, "name", "x"),
+		"err", errors.New("boom"),
+	)
+	logger.Log(ctx, slog.LevelWarn+1, "warn plus")
+
+	entries := logs.AllUntimed()
+	ass.Len(entries, 2)
+	ass.Equal("handled", entries[0].Message)
+	ass.Equal(InfoLevel, entries[0].Level)
+	ass.Contains(entries[0].Caller.TrimmedPath(), "log/slog_test.go:23")
+	ass.Equal(map[string]interface{}{
+		IdKey:     "id-1",
+		"service": "demo",
+		"req": map[string]interface{}{
+			"status": int64(200),
+			"user":   map[string]interface{}{"id": int64(7), "name": "x"},
+			"err":    "boom",
+		},
+	}, entries[0].ContextMap())
+	ass.Equal(WarnLevel, entries[1].Level)
+}
+
+func TestRedirectSlog(t *testing.T) {
+	ass := assert.New(t)
+	core, logs := observer.New(DebugLevel)
+	restore := RedirectSlog(NewLoggerWithCore(core, DebugLevel))
+	slog.Info("via slog", "k", "v")
+	restore()
+	slog.Info("after restore")
+
+	entries := logs.AllUntimed()
+	ass.Len(entries, 1)
+	ass.Equal("via slog", entries[0].Message)
+	ass.Contains(entries[0].Caller.TrimmedPath(), "log/slog_test.go:51")
+}
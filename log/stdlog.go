@@ -0,0 +1,43 @@
+package log
+
+import (
+	stdlog "log"
+	"strings"
+)
+
+// stdWriter 将标准库 logger 的输出写入 Logger
+type stdWriter struct {
+	logger *Logger
+	level  Level
+	prefix string
+}
+
+func (w *stdWriter) Write(p []byte) (int, error) {
+	msg := strings.TrimSuffix(string(p), "\n")
+	msg = strings.TrimPrefix(msg, w.prefix)
+	w.logger.log(w.level, msg, nil, nil)
+	return len(p), nil
+}
+
+// newStdWriter 输出日志位置时会跳过标准库 logger 的调用栈
+func newStdWriter(l *Logger, level Level, prefix string) *stdWriter {
+	return &stdWriter{logger: l, level: level, prefix: prefix}
+}
+
+// NewStdLogger 创建标准库 *log.Logger，日志以 level 等级写入 l
+// 以 prefix 开头的日志会去除 prefix，时间等信息由 l 输出
+func NewStdLogger(l *Logger, level Level, prefix string) *stdlog.Logger {
+	return stdlog.New(newStdWriter(l, level, prefix), "", 0)
+}
+
+// RedirectStdLog 将标准库全局 logger 的输出以 level 等级写入 l，返回恢复原配置的函数
+// 全局 logger 通过 log.SetPrefix 设置的前缀会被去除
+func RedirectStdLog(l *Logger, level Level) (restore func()) {
+	flags, prefix, writer := stdlog.Flags(), stdlog.Prefix(), stdlog.Writer()
+	stdlog.SetFlags(0)
+	stdlog.SetOutput(newStdWriter(l, level, prefix))
+	return func() {
+		stdlog.SetFlags(flags)
+		stdlog.SetOutput(writer)
+	}
+}
@@ -0,0 +1,44 @@
+package log
+
+import (
+	stdlog "log"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestNewStdLogger(t *testing.T) {
+	ass := assert.New(t)
+	core, logs := observer.New(DebugLevel)
+	logger := NewLoggerWithCore(core, DebugLevel)
+
+	std := NewStdLogger(logger, WarnLevel, "[lib] ")
+	std.Printf("[lib] retry %d", 3)
+	std.Println("plain")
+
+	entries := logs.AllUntimed()
+	ass.Len(entries, 2)
+	ass.Equal(WarnLevel, entries[0].Level)
+	ass.Equal("retry 3", entries[0].Message)
+	ass.Equal("plain", entries[1].Message)
+	ass.Contains(entries[0].Caller.TrimmedPath(), "log/stdlog_test.go:17")
+}
+
+func TestRedirectStdLog(t *testing.T) {
+	ass := assert.New(t)
+	core, logs := observer.New(DebugLevel)
+	logger := NewLoggerWithCore(core, DebugLevel)
+
+	stdlog.SetPrefix("app: ")
+	restore := RedirectStdLog(logger, InfoLevel)
+	stdlog.Print("hello std")
+	restore()
+	stdlog.SetPrefix("")
+
+	entries := logs.AllUntimed()
+	ass.Len(entries, 1)
+	ass.Equal("hello std", entries[0].Message)
+	ass.Contains(entries[0].Caller.TrimmedPath(), "log/stdlog_test.go:35")
+	ass.Equal(stdlog.LstdFlags, stdlog.Flags())
+}
@@ -5,6 +5,7 @@ import (
 	"io/ioutil"
 	"testing"
 
+	"github.com/sanbsy/gopkg/errors"
 	"github.com/stretchr/testify/require"
 )
 
@@ -104,3 +105,14 @@ func TestECDSASign(t *testing.T) {
 	err = ECDSAVerify(content, signature, SigningMethodRSA512, ecdsaKey.PublicKey())
 	r.Nil(err)
 }
+
+func TestParseRSAKey_Errors(t *testing.T) {
+	r := require.New(t)
+
+	_, err := ParseRSAPublicKey([]byte("not pem"))
+	r.Equal(ErrKeyMustBePEMEncoded, err)
+	r.Equal("secret.key_not_pem", errors.Code(err))
+
+	_, err = ParseRSAPrivateKey([]byte("not pem"))
+	r.True(errors.Is(err, ErrKeyMustBePEMEncoded))
+}
@@ -9,10 +9,11 @@ import (
 	"github.com/sanbsy/gopkg/errors"
 )
 
+// 以 error 类型声明，保持与之前版本兼容，通过 errors.Code 获取错误码
 var (
-	ErrKeyMustBePEMEncoded = errors.New("invalid key: Key must be PEM encoded")
-	ErrNotRSAPrivateKey    = errors.New("key is not a valid RSA private key")
-	ErrNotRSAPublicKey     = errors.New("key is not a valid RSA public key")
+	ErrKeyMustBePEMEncoded error = errors.Define("secret.key_not_pem", errors.InvalidArgument, "invalid key: Key must be PEM encoded")
+	ErrNotRSAPrivateKey    error = errors.Define("secret.not_rsa_private_key", errors.InvalidArgument, "key is not a valid RSA private key")
+	ErrNotRSAPublicKey     error = errors.Define("secret.not_rsa_public_key", errors.InvalidArgument, "key is not a valid RSA public key")
 )
 
 type RSAKey struct {
@@ -22,6 +22,14 @@ const (
 	ErrSigningMethodError   = "错误的签名方法"
 )
 
+// 可通过 errors.Is 匹配的错误码
+var (
+	ErrBase64Decode    = errors.Define("secret.base64_decode", errors.InvalidArgument, ErrBase64DecodeError)
+	ErrSignatureCheck  = errors.Define("secret.signature_check", errors.Unauthenticated, ErrSignatureCheckError)
+	ErrSignatureCreate = errors.Define("secret.signature_create", errors.Internal, ErrSignatureCreateError)
+	ErrSigningMethod   = errors.Define("secret.signing_method", errors.InvalidArgument, ErrSigningMethodError)
+)
+
 const (
 	SigningMethodRSA256 = "SHA256"
 	SigningMethodRSA512 = "SHA512"
@@ -39,12 +47,12 @@ var signingMethodMap = map[string]crypto.Hash{
 func RSASign(data []byte, method string, privateKey *rsa.PrivateKey) (string, error) {
 	hashed, err := hashSum(data, method)
 	if err != nil {
-		return "", errors.Wrap(err, ErrSigningMethodError)
+		return "", ErrSigningMethod.Wrap(err, "")
 	}
 
 	signature, err := rsa.SignPKCS1v15(rand.Reader, privateKey, signingMethodMap[method], hashed)
 	if err != nil {
-		return "", errors.Wrap(err, ErrSignatureCreateError)
+		return "", ErrSignatureCreate.Wrap(err, "")
 	}
 	return base64.StdEncoding.EncodeToString(signature), nil
 }
@@ -53,18 +61,18 @@ func RSASign(data []byte, method string, privateKey *rsa.PrivateKey) (string, er
 func RSAVerify(content []byte, signature string, method string, publicKey *rsa.PublicKey) error {
 	hashed, err := hashSum(content, method)
 	if err != nil {
-		return errors.Wrap(err, ErrSigningMethodError)
+		return ErrSigningMethod.Wrap(err, "")
 	}
 	// base64 解码 签名
 	sign, err := base64.StdEncoding.DecodeString(signature)
 	if err != nil {
-		return errors.Wrap(err, ErrBase64DecodeError)
+		return ErrBase64Decode.Wrap(err, "")
 	}
 
 	// 返回验签结果
 	err = rsa.VerifyPKCS1v15(publicKey, signingMethodMap[method], hashed[:], sign)
 	if err != nil {
-		return errors.Wrap(err, ErrSignatureCheckError)
+		return ErrSignatureCheck.Wrap(err, "")
 	}
 	return nil
 }
@@ -73,17 +81,17 @@ func RSAVerify(content []byte, signature string, method string, publicKey *rsa.P
 func ECDSASign(data []byte, method string, privateKey *ecdsa.PrivateKey) (string, error) {
 	hashed, err := hashSum(data, method)
 	if err != nil {
-		return "", errors.Wrap(err, ErrSigningMethodError)
+		return "", ErrSigningMethod.Wrap(err, "")
 	}
 
 	r, s, err := ecdsa.Sign(rand.Reader, privateKey, hashed)
 	rt, err := r.MarshalText()
 	if err != nil {
-		return "", errors.Wrap(err, ErrSignatureCreateError)
+		return "", ErrSignatureCreate.Wrap(err, "")
 	}
 	st, err := s.MarshalText()
 	if err != nil {
-		return "", errors.Wrap(err, ErrSignatureCreateError)
+		return "", ErrSignatureCreate.Wrap(err, "")
 	}
 
 	buf := new(bytes.Buffer)
@@ -98,20 +106,20 @@ func ECDSASign(data []byte, method string, privateKey *ecdsa.PrivateKey) (string
 func ECDSAVerify(content []byte, signature string, method string, publicKey *ecdsa.PublicKey) error {
 	hashed, err := hashSum(content, method)
 	if err != nil {
-		return errors.Wrap(err, ErrSigningMethodError)
+		return ErrSigningMethod.Wrap(err, "")
 	}
 	signatureBytes, err := base64.StdEncoding.DecodeString(signature)
 	if err != nil {
-		return errors.Wrap(err, ErrBase64DecodeError)
+		return ErrBase64Decode.Wrap(err, "")
 	}
 	r, s, err := parseECDSASignature(signatureBytes)
 	if err != nil {
-		return errors.Wrap(err, ErrSignatureCheckError)
+		return ErrSignatureCheck.Wrap(err, "")
 	}
 
 	cr := ecdsa.Verify(publicKey, hashed, r, s)
 	if !cr {
-		return errors.New(ErrSignatureCheckError)
+		return ErrSignatureCheck.WithDetail("ecdsa verify failed")
 	}
 	return nil
 
@@ -134,7 +142,7 @@ func HMACSHA512(data, key []byte) string {
 func hashSum(data []byte, method string) ([]byte, error) {
 	var hashed []byte
 	if _, ok := signingMethodMap[method]; !ok {
-		return nil, errors.New("invalid singing method.")
+		return nil, errors.Errorf("invalid signing method: %s", method)
 	}
 	switch method {
 	case SigningMethodRSA256:
@@ -147,7 +155,7 @@ func hashSum(data []byte, method string) ([]byte, error) {
 		h := sha512.Sum512(data)
 		hashed = h[:]
 	default:
-		return nil, errors.New("invalid signing method.")
+		return nil, errors.Errorf("invalid signing method: %s", method)
 	}
 	return hashed, nil
 }
@@ -8,6 +8,14 @@ import (
 	"github.com/sanbsy/gopkg/errors"
 )
 
+// ErrNotFound 查询结果为空
+var ErrNotFound = errors.Define("sqler.not_found", errors.NotFound, "record not found")
+
+func init() {
+	errors.RegisterSentinel(scanner.ErrEmptyResult, errors.NotFound)
+	errors.RegisterSentinel(sql.ErrNoRows, errors.NotFound)
+}
+
 type Fruit struct {
 	rows *sql.Rows
 	err  error
@@ -32,6 +40,9 @@ func (rs *Fruit) ConvertTo(target interface{}) error {
 	defer rs.Close()
 
 	if err := scanner.Scan(rs.rows, target); err != nil {
+		if err == scanner.ErrEmptyResult {
+			return ErrNotFound.Wrap(err, "")
+		}
 		return err
 	}
 	return nil
@@ -54,5 +65,5 @@ func (rs *Fruit) ConvertToMap() ([]map[string]interface{}, error) {
 }
 
 func IsNotFoundError(err error) bool {
-	return errors.Is(err, scanner.ErrEmptyResult)
+	return errors.Is(err, ErrNotFound) || errors.Is(err, scanner.ErrEmptyResult)
 }
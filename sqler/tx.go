@@ -5,6 +5,7 @@ import (
 	"database/sql"
 
 	"github.com/didi/gendry/builder"
+	"github.com/sanbsy/gopkg/errors"
 	"github.com/sanbsy/gopkg/internal/idx"
 )
 
@@ -121,10 +122,33 @@ func (tx *Tx) RollbackSavePoint(tag string) error {
 
 // Commit 事务提交
 func (tx *Tx) Commit() error {
-	return tx.Commit()
+	return tx.Tx.Commit()
 }
 
 // Rollback 回滚事务
 func (tx *Tx) Rollback() error {
-	return tx.Rollback()
+	return tx.Tx.Rollback()
+}
+
+// Transaction 在事务中执行 fn，fn 返回错误或发生 panic 时回滚，否则提交
+// panic 会被转换为 *errors.PanicError 返回
+func (db *DB) Transaction(ctx context.Context, opts *TxOptions, fn func(tx *Tx) error) (err error) {
+	tx, err := db.BeginCtx(ctx, opts)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if err != nil {
+			if rbErr := tx.Rollback(); rbErr != nil {
+				err = errors.Append(err, rbErr)
+			}
+			return
+		}
+		err = tx.Commit()
+	}()
+
+	return errors.Try(func() error {
+		return fn(tx)
+	})
 }
@@ -3,10 +3,17 @@ package store
 import (
 	"fmt"
 
+	"github.com/sanbsy/gopkg/errors"
 	"github.com/sanbsy/gopkg/internal/idx"
 	bolt "go.etcd.io/bbolt"
 )
 
+// 可通过 errors.Is 匹配的错误码
+var (
+	ErrBucketNotFound = errors.Define("store.bucket_not_found", errors.NotFound, "this bucket does not exist")
+	ErrKeyNotFound    = errors.Define("store.key_not_found", errors.NotFound, "this key does not exist")
+)
+
 type (
 	BoltStorage struct {
 		dbPath        string
@@ -110,7 +117,7 @@ func (bb *BoltBucket) Set(key, value []byte) error {
 	return bb.db.Update(func(tx *bolt.Tx) error {
 		b := tx.Bucket(bb.name)
 		if b == nil {
-			return fmt.Errorf("this bucket does not exist: %s", string(bb.name))
+			return errors.With(ErrBucketNotFound.WithDetail(string(bb.name)), "bucket", string(bb.name))
 		}
 		return b.Put(key, value)
 	})
@@ -122,11 +129,11 @@ func (bb *BoltBucket) Get(key []byte) ([]byte, error) {
 	err := bb.db.View(func(tx *bolt.Tx) error {
 		b := tx.Bucket(bb.name)
 		if b == nil {
-			return fmt.Errorf("this bucket does not exist: %s", string(bb.name))
+			return errors.With(ErrBucketNotFound.WithDetail(string(bb.name)), "bucket", string(bb.name))
 		}
 		value = b.Get(key)
 		if value == nil {
-			return fmt.Errorf("this key does not exist: %s", string(key))
+			return errors.With(ErrKeyNotFound.WithDetail(string(key)), "bucket", string(bb.name), "key", string(key))
 		}
 		return nil
 	})
@@ -138,7 +145,7 @@ func (bb *BoltBucket) Delete(key []byte) error {
 	return bb.db.Update(func(tx *bolt.Tx) error {
 		b := tx.Bucket(bb.name)
 		if b == nil {
-			return fmt.Errorf("this bucket does not exist: %s", string(bb.name))
+			return errors.With(ErrBucketNotFound.WithDetail(string(bb.name)), "bucket", string(bb.name))
 		}
 		return b.Delete(key)
 	})
@@ -150,7 +157,7 @@ func (bb *BoltBucket) All() (map[string][]byte, error) {
 	err := bb.db.View(func(tx *bolt.Tx) error {
 		b := tx.Bucket(bb.name)
 		if b == nil {
-			return fmt.Errorf("this bucket does not exist: %s", string(bb.name))
+			return errors.With(ErrBucketNotFound.WithDetail(string(bb.name)), "bucket", string(bb.name))
 		}
 		err := b.ForEach(func(k, v []byte) error {
 			result[string(k)] = v
@@ -166,7 +173,7 @@ func (bb *BoltBucket) BatchUpdate(key, value []byte) error {
 	return bb.db.Batch(func(tx *bolt.Tx) error {
 		b := tx.Bucket(bb.name)
 		if b == nil {
-			return fmt.Errorf("this bucket does not exist: %s", string(bb.name))
+			return errors.With(ErrBucketNotFound.WithDetail(string(bb.name)), "bucket", string(bb.name))
 		}
 		return b.Put(key, value)
 	})
@@ -5,6 +5,7 @@ import (
 	"path"
 	"testing"
 
+	"github.com/sanbsy/gopkg/errors"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -25,3 +26,14 @@ func TestBoltBucket_Set(t *testing.T) {
 
 	ass.Equal([]byte("test_value_2"), value)
 }
+
+func TestBoltBucket_GetNotFound(t *testing.T) {
+	ass := assert.New(t)
+	sto, err := NewBolt(path.Join(t.TempDir(), "data.db"))
+	ass.Nil(err)
+	defer sto.Close()
+
+	_, err = sto.Get([]byte("missing_key"))
+	ass.True(errors.Is(err, ErrKeyNotFound))
+	ass.True(errors.IsCategory(err, errors.NotFound))
+}